@@ -0,0 +1,209 @@
+package bot
+
+import (
+	"discord-mod-bot/internal/config"
+	"discord-mod-bot/internal/store"
+	"discord-mod-bot/internal/utils"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// errMuteRoleNotConfigured is returned by muteMember when the mute needs
+// the role but MUTE_ROLE_ID is unset
+var errMuteRoleNotConfigured = errors.New("mute role not configured")
+
+// Mute modes (MUTE_MODE)
+const (
+	muteModeRole    = "role"    // always use MUTE_ROLE_ID
+	muteModeTimeout = "timeout" // Discord timeouts, the role for longer mutes
+)
+
+// maxTimeout is the longest timeout Discord accepts
+const maxTimeout = 28 * 24 * time.Hour
+
+// useTimeout reports whether a mute of duration is applied as a Discord
+// timeout. Permanent mutes and ones over 28 days need the mute role.
+func useTimeout(duration time.Duration) bool {
+	return config.Cfg.MuteMode == muteModeTimeout && duration > 0 && duration <= maxTimeout
+}
+
+// The functions below are the shared core of the moderation commands: they
+// perform the Discord action, record the case and post it to the log
+// channel. Permission checks and replies stay with the callers.
+
+// banMember bans userID. A zero duration bans permanently; otherwise the
+// unban is scheduled.
+func (b *Bot) banMember(s *discordgo.Session, guildID, moderatorID, userID, reason string, duration time.Duration) (*store.Case, error) {
+	if err := s.GuildBanCreateWithReason(guildID, userID, reason, 0); err != nil {
+		return nil, err
+	}
+	b.trackDestructiveAction(s, guildID, moderatorID, nukeActionBan)
+
+	if duration <= 0 {
+		// A permanent ban replaces any earlier temporary one
+		b.cancelExpiry(expiryActionUnban, guildID, userID)
+		c := b.recordCase(guildID, store.ActionBan, moderatorID, userID, reason, time.Time{})
+		b.logCase(s, c)
+		return c, nil
+	}
+
+	expiresAt := time.Now().Add(duration)
+	c := b.recordCase(guildID, store.ActionTempban, moderatorID, userID, reason, expiresAt)
+	b.scheduleExpiry(expiry{
+		Action:      expiryActionUnban,
+		GuildID:     guildID,
+		UserID:      userID,
+		ModeratorID: moderatorID,
+		CaseID:      c.ID,
+		ExpiresAt:   expiresAt,
+	})
+	b.logCase(s, c)
+	return c, nil
+}
+
+// kickMember removes userID from the guild
+func (b *Bot) kickMember(s *discordgo.Session, guildID, moderatorID, userID, reason string) (*store.Case, error) {
+	if err := s.GuildMemberDeleteWithReason(guildID, userID, reason); err != nil {
+		return nil, err
+	}
+	b.trackDestructiveAction(s, guildID, moderatorID, nukeActionKick)
+
+	c := b.recordCase(guildID, store.ActionKick, moderatorID, userID, reason, time.Time{})
+	b.logCase(s, c)
+	return c, nil
+}
+
+// muteMember times out userID or adds the mute role, depending on the mute
+// mode and duration. A zero duration mutes permanently; timed role mutes get
+// their unmute scheduled, while Discord lifts timeouts by itself.
+func (b *Bot) muteMember(s *discordgo.Session, guildID, moderatorID, userID, reason string, duration time.Duration) (*store.Case, error) {
+	if useTimeout(duration) {
+		return b.timeoutMember(s, guildID, moderatorID, userID, reason, duration)
+	}
+	return b.roleMuteMember(s, guildID, moderatorID, userID, reason, duration)
+}
+
+// timeoutMember times out userID for duration, which must be between zero
+// and 28 days. Discord lifts the timeout by itself.
+func (b *Bot) timeoutMember(s *discordgo.Session, guildID, moderatorID, userID, reason string, duration time.Duration) (*store.Case, error) {
+	until := time.Now().Add(duration)
+	if err := s.GuildMemberTimeout(guildID, userID, &until); err != nil {
+		return nil, err
+	}
+
+	// The timeout replaces an earlier timed role mute, so its unmute mustn't
+	// fire later, and without it nothing would ever take the role off
+	cancelled, err := b.scheduler.cancel(expiryActionUnmute, guildID, userID)
+	if err != nil {
+		log.Printf("Scheduler: Error cancelling %s expiry for user %s: %v", expiryActionUnmute, userID, err)
+	}
+	if cancelled && config.Cfg.MuteRoleID != "" {
+		if err := s.GuildMemberRoleRemove(guildID, userID, config.Cfg.MuteRoleID); err != nil {
+			log.Printf("Scheduler: Error removing the replaced mute role from user %s: %v", userID, err)
+		}
+	}
+
+	c := b.recordCase(guildID, store.ActionMute, moderatorID, userID, reason, until)
+	b.logCase(s, c)
+	return c, nil
+}
+
+// roleMuteMember adds the mute role to userID, scheduling its removal for
+// timed mutes
+func (b *Bot) roleMuteMember(s *discordgo.Session, guildID, moderatorID, userID, reason string, duration time.Duration) (*store.Case, error) {
+	if config.Cfg.MuteRoleID == "" {
+		return nil, errMuteRoleNotConfigured
+	}
+
+	if err := s.GuildMemberRoleAdd(guildID, userID, config.Cfg.MuteRoleID); err != nil {
+		return nil, err
+	}
+
+	if duration <= 0 {
+		// A permanent mute replaces any earlier timed one
+		b.cancelExpiry(expiryActionUnmute, guildID, userID)
+		c := b.recordCase(guildID, store.ActionMute, moderatorID, userID, reason, time.Time{})
+		b.logCase(s, c)
+		return c, nil
+	}
+
+	expiresAt := time.Now().Add(duration)
+	c := b.recordCase(guildID, store.ActionMute, moderatorID, userID, reason, expiresAt)
+	b.scheduleExpiry(expiry{
+		Action:      expiryActionUnmute,
+		GuildID:     guildID,
+		UserID:      userID,
+		ModeratorID: moderatorID,
+		CaseID:      c.ID,
+		ExpiresAt:   expiresAt,
+	})
+	b.logCase(s, c)
+	return c, nil
+}
+
+// quarantineMember cuts off a member, typically a compromised account, by
+// adding QUARANTINE_ROLE_ID. Without the role it uses the longest timeout.
+func (b *Bot) quarantineMember(s *discordgo.Session, guildID, moderatorID, userID, reason string) (*store.Case, error) {
+	var expiresAt time.Time
+	if config.Cfg.QuarantineRoleID != "" {
+		if err := s.GuildMemberRoleAdd(guildID, userID, config.Cfg.QuarantineRoleID); err != nil {
+			return nil, err
+		}
+	} else {
+		expiresAt = time.Now().Add(maxTimeout)
+		if err := s.GuildMemberTimeout(guildID, userID, &expiresAt); err != nil {
+			return nil, err
+		}
+	}
+
+	c := b.recordCase(guildID, store.ActionQuarantine, moderatorID, userID, reason, expiresAt)
+	b.logCase(s, c)
+	return c, nil
+}
+
+// unmuteMember lifts a timeout and removes the mute role, whichever of the
+// two userID has. It returns false if the member wasn't muted.
+func (b *Bot) unmuteMember(s *discordgo.Session, guildID, userID string) (bool, error) {
+	member, err := utils.GetMember(s, guildID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	// Nothing is left to expire after a manual unmute
+	b.cancelExpiry(expiryActionUnmute, guildID, userID)
+
+	unmuted := false
+	if until := member.CommunicationDisabledUntil; until != nil && until.After(time.Now()) {
+		if err := s.GuildMemberTimeout(guildID, userID, nil); err != nil {
+			return false, err
+		}
+		unmuted = true
+	}
+
+	if config.Cfg.MuteRoleID != "" {
+		for _, roleID := range member.Roles {
+			if roleID != config.Cfg.MuteRoleID {
+				continue
+			}
+			if err := s.GuildMemberRoleRemove(guildID, userID, roleID); err != nil {
+				return unmuted, err
+			}
+			unmuted = true
+			break
+		}
+	}
+
+	return unmuted, nil
+}
+
+// botUserID returns the bot's own user ID, used as moderator for
+// automatic actions
+func botUserID(s *discordgo.Session) string {
+	if s.State != nil && s.State.User != nil {
+		return s.State.User.ID
+	}
+	return ""
+}
@@ -0,0 +1,186 @@
+package bot
+
+import (
+	"discord-mod-bot/internal/config"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Destructive action kinds tracked by the anti-nuke watchdog
+const (
+	nukeActionBan           = "ban"
+	nukeActionKick          = "kick"
+	nukeActionRoleRemove    = "role removal"
+	nukeActionChannelDelete = "channel delete"
+)
+
+// nukeWatchdog counts destructive actions per actor in a sliding window
+type nukeWatchdog struct {
+	mu      sync.Mutex
+	actions map[string][]nukeAction // actorID -> recent actions, oldest first
+}
+
+type nukeAction struct {
+	kind string
+	at   time.Time
+}
+
+func newNukeWatchdog() *nukeWatchdog {
+	return &nukeWatchdog{actions: make(map[string][]nukeAction)}
+}
+
+// record adds an action for actorID and reports whether the actor crossed
+// the threshold. On a trip the actor's history is returned and cleared so
+// one burst only triggers once.
+func (w *nukeWatchdog) record(actorID, kind string, now time.Time, window time.Duration, threshold int) ([]nukeAction, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	recent := w.actions[actorID][:0:0]
+	for _, a := range w.actions[actorID] {
+		if now.Sub(a.at) <= window {
+			recent = append(recent, a)
+		}
+	}
+	recent = append(recent, nukeAction{kind: kind, at: now})
+
+	if len(recent) >= threshold {
+		delete(w.actions, actorID)
+		return recent, true
+	}
+
+	w.actions[actorID] = recent
+	return nil, false
+}
+
+// trackDestructiveAction feeds the anti-nuke watchdog. actorID is the
+// moderator for bot-issued actions or the audit log user for actions taken
+// in the Discord client.
+func (b *Bot) trackDestructiveAction(s *discordgo.Session, guildID, actorID, kind string) {
+	if !config.Cfg.AntiNukeEnabled || actorID == "" {
+		return
+	}
+
+	// The bot's own automatic actions are attributed to moderators already
+	if actorID == botUserID(s) {
+		return
+	}
+
+	window := time.Duration(config.Cfg.AntiNukeWindow) * time.Second
+	actions, tripped := b.nukeWatchdog.record(actorID, kind, time.Now(), window, config.Cfg.AntiNukeThreshold)
+	if !tripped {
+		return
+	}
+
+	log.Printf("AntiNuke: User %s performed %d destructive actions within %s, stripping roles", actorID, len(actions), window)
+	b.containNuke(s, guildID, actorID, actions, window)
+}
+
+// containNuke strips the actor's moderation roles and alerts the log channel
+func (b *Bot) containNuke(s *discordgo.Session, guildID, actorID string, actions []nukeAction, window time.Duration) {
+	var stripped, failed []string
+
+	member, err := s.GuildMember(guildID, actorID)
+	if err != nil {
+		log.Printf("AntiNuke: Error getting member %s: %v", actorID, err)
+	} else {
+		roleMap := make(map[string]bool, len(member.Roles))
+		for _, roleID := range member.Roles {
+			roleMap[roleID] = true
+		}
+
+		for _, roleID := range []string{config.Cfg.AdminRoleID, config.Cfg.StaffRoleID, config.Cfg.ModRoleID} {
+			if roleID == "" || !roleMap[roleID] {
+				continue
+			}
+
+			if err := s.GuildMemberRoleRemove(guildID, actorID, roleID); err != nil {
+				log.Printf("AntiNuke: Error removing role %s from %s: %v", roleID, actorID, err)
+				failed = append(failed, fmt.Sprintf("<@&%s>", roleID))
+				continue
+			}
+			stripped = append(stripped, fmt.Sprintf("<@&%s>", roleID))
+		}
+	}
+
+	if logChannel(logModeration) == "" {
+		return
+	}
+
+	counts := make(map[string]int)
+	var kinds []string
+	for _, a := range actions {
+		if counts[a.kind] == 0 {
+			kinds = append(kinds, a.kind)
+		}
+		counts[a.kind]++
+	}
+	var summary []string
+	for _, kind := range kinds {
+		summary = append(summary, fmt.Sprintf("%d× %s", counts[kind], kind))
+	}
+
+	strippedText := "None"
+	if len(stripped) > 0 {
+		strippedText = strings.Join(stripped, ", ")
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "🚨 Anti-Nuke Triggered",
+		Description: fmt.Sprintf("<@%s> performed **%d** destructive actions within %s.", actorID, len(actions), formatDuration(window)),
+		Color:       0xED4245, // Red
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Actions", Value: strings.Join(summary, "\n"), Inline: false},
+			{Name: "Roles Stripped", Value: strippedText, Inline: false},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	if len(failed) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "⚠️ Failed To Strip",
+			Value:  strings.Join(failed, ", ") + "\nCheck the bot's role is above these roles.",
+			Inline: false,
+		})
+	}
+
+	if len(stripped) == 0 && len(failed) == 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "⚠️ Manual Action Needed",
+			Value:  "The user holds none of the configured admin/staff/mod roles. Their permissions come from another role and must be removed manually.",
+			Inline: false,
+		})
+	}
+
+	var pings []string
+	for _, ownerID := range config.Cfg.AntiNukeOwnerIDs {
+		pings = append(pings, fmt.Sprintf("<@%s>", ownerID))
+	}
+
+	_, err = sendLog(s, logModeration, &discordgo.MessageSend{
+		Content: strings.Join(pings, " "),
+		Embed:   embed,
+	})
+	if err != nil {
+		log.Printf("AntiNuke: Error sending alert: %v", err)
+	}
+}
+
+// onChannelDelete tracks who deleted a channel
+func (b *Bot) onChannelDelete(s *discordgo.Session, e *discordgo.ChannelDelete) {
+	if !config.Cfg.AntiNukeEnabled || e.Channel == nil || e.GuildID != config.Cfg.GuildID {
+		return
+	}
+
+	entry := findAuditEntry(s, e.GuildID, e.ID, discordgo.AuditLogActionChannelDelete)
+	if entry == nil {
+		return
+	}
+
+	b.trackDestructiveAction(s, e.GuildID, entry.UserID, nukeActionChannelDelete)
+}
@@ -0,0 +1,418 @@
+package bot
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// argErrorKind says why an argument couldn't be parsed
+type argErrorKind int
+
+const (
+	argErrMissing      argErrorKind = iota // required argument not given
+	argErrInvalid                          // value doesn't parse or resolve
+	argErrAmbiguous                        // name matches more than one thing
+	argErrUnterminated                     // opening quote without closing one
+)
+
+// argError is a parse failure that should be shown to the user
+type argError struct {
+	Kind   argErrorKind
+	Arg    string // argument name, empty for tokenizer errors
+	Value  string // raw input that failed
+	Reason string // user-facing explanation
+	Usage  string // usage text of the command, if it helps
+}
+
+func (e *argError) Error() string {
+	if e.Kind == argErrMissing {
+		return e.Usage
+	}
+
+	msg := "❌ " + e.Reason
+	if e.Usage != "" {
+		msg += "\n" + e.Usage
+	}
+	return msg
+}
+
+// argToken is one word of command input
+type argToken struct {
+	Value string // the word, without surrounding quotes
+	Rest  string // the input from this word on, verbatim
+}
+
+// splitArgs splits command input into words. Text in double quotes
+// (including the “curly” ones mobile keyboards insert) is kept as one word,
+// and \" inside quotes is a literal quote.
+func splitArgs(input string) ([]argToken, error) {
+	var tokens []argToken
+	var word strings.Builder
+	inWord := false
+	start := 0
+	var closing rune // quote that ends the current quoted word, 0 if none
+	escaped := false
+
+	flush := func() {
+		tokens = append(tokens, argToken{Value: word.String(), Rest: strings.TrimSpace(input[start:])})
+		word.Reset()
+		inWord = false
+	}
+
+	for i, r := range input {
+		switch {
+		case closing != 0:
+			if escaped {
+				word.WriteRune(r)
+				escaped = false
+			} else if r == '\\' {
+				escaped = true
+			} else if r == closing || (closing == '”' && r == '"') {
+				closing = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				flush()
+			}
+		case !inWord && (r == '"' || r == '“'):
+			// Quotes only group at the start of a word, so don"t stays literal
+			inWord = true
+			start = i
+			closing = '"'
+			if r == '“' {
+				closing = '”'
+			}
+		default:
+			if !inWord {
+				inWord = true
+				start = i
+			}
+			word.WriteRune(r)
+		}
+	}
+
+	if closing != 0 {
+		return nil, &argError{Kind: argErrUnterminated, Reason: "Missing closing quote in arguments."}
+	}
+	if inWord {
+		flush()
+	}
+	return tokens, nil
+}
+
+var (
+	userMentionPattern    = regexp.MustCompile(`^<@!?(\d+)>$`)
+	roleMentionPattern    = regexp.MustCompile(`^<@&(\d+)>$`)
+	channelMentionPattern = regexp.MustCompile(`^<#(\d+)>$`)
+)
+
+// isSnowflake reports whether s looks like a Discord ID
+func isSnowflake(s string) bool {
+	if len(s) < 17 || len(s) > 20 {
+		return false
+	}
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil
+}
+
+// parseUserID extracts a user ID from a mention or raw ID, or returns ""
+func parseUserID(mention string) string {
+	mention = strings.TrimSpace(mention)
+	if m := userMentionPattern.FindStringSubmatch(mention); m != nil {
+		return m[1]
+	}
+	if isSnowflake(mention) {
+		return mention
+	}
+	return ""
+}
+
+// resolveUser turns a mention, ID, username or nickname into
+// a user ID. Names are looked up in the state cache; IDs are accepted as-is
+// so users who left or are banned can still be targeted.
+func resolveUser(s *discordgo.Session, guildID, raw string) (string, *argError) {
+	if id := parseUserID(raw); id != "" {
+		return id, nil
+	}
+
+	name := strings.ToLower(strings.TrimPrefix(raw, "@"))
+	username, discriminator, hasDiscriminator := strings.Cut(name, "#")
+
+	var matches []string
+	if s.State != nil {
+		if guild, err := s.State.Guild(guildID); err == nil {
+			for _, member := range guild.Members {
+				if member.User == nil {
+					continue
+				}
+				u := member.User
+				var match bool
+				if hasDiscriminator {
+					match = strings.ToLower(u.Username) == username && u.Discriminator == discriminator
+				} else {
+					match = strings.ToLower(u.Username) == name || strings.ToLower(member.Nick) == name
+				}
+				if match {
+					matches = append(matches, u.ID)
+				}
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", &argError{Kind: argErrInvalid, Value: raw, Reason: fmt.Sprintf("Couldn't find a member matching `%s`. Use a mention, user ID or username.", raw)}
+	case 1:
+		return matches[0], nil
+	default:
+		return "", &argError{Kind: argErrAmbiguous, Value: raw, Reason: fmt.Sprintf("`%s` matches %d members, use a mention or user ID instead.", raw, len(matches))}
+	}
+}
+
+// resolveRole turns a role mention, ID or name into a role ID
+func resolveRole(s *discordgo.Session, guildID, raw string) (string, *argError) {
+	if m := roleMentionPattern.FindStringSubmatch(raw); m != nil {
+		return m[1], nil
+	}
+	if isSnowflake(raw) {
+		return raw, nil
+	}
+
+	name := strings.ToLower(strings.TrimPrefix(raw, "@"))
+	var matches []string
+	if s.State != nil {
+		if guild, err := s.State.Guild(guildID); err == nil {
+			for _, role := range guild.Roles {
+				if strings.ToLower(role.Name) == name {
+					matches = append(matches, role.ID)
+				}
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", &argError{Kind: argErrInvalid, Value: raw, Reason: fmt.Sprintf("Couldn't find a role matching `%s`.", raw)}
+	case 1:
+		return matches[0], nil
+	default:
+		return "", &argError{Kind: argErrAmbiguous, Value: raw, Reason: fmt.Sprintf("`%s` matches %d roles, use a mention or role ID instead.", raw, len(matches))}
+	}
+}
+
+// resolveChannel turns a channel mention, ID or name into a channel ID
+func resolveChannel(s *discordgo.Session, guildID, raw string) (string, *argError) {
+	if m := channelMentionPattern.FindStringSubmatch(raw); m != nil {
+		return m[1], nil
+	}
+	if isSnowflake(raw) {
+		return raw, nil
+	}
+
+	name := strings.ToLower(strings.TrimPrefix(raw, "#"))
+	var matches []string
+	if s.State != nil {
+		if guild, err := s.State.Guild(guildID); err == nil {
+			for _, channel := range guild.Channels {
+				if strings.ToLower(channel.Name) == name {
+					matches = append(matches, channel.ID)
+				}
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", &argError{Kind: argErrInvalid, Value: raw, Reason: fmt.Sprintf("Couldn't find a channel matching `%s`.", raw)}
+	case 1:
+		return matches[0], nil
+	default:
+		return "", &argError{Kind: argErrAmbiguous, Value: raw, Reason: fmt.Sprintf("`%s` matches %d channels, use a mention or channel ID instead.", raw, len(matches))}
+	}
+}
+
+// durationPattern matches one number+unit component of a human duration
+var durationPattern = regexp.MustCompile(`(\d+)([smhdw])`)
+
+// parseDuration parses human durations like 10m, 2h, 7d, 1w or 1d12h
+func parseDuration(input string) (time.Duration, error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	if input == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	matches := durationPattern.FindAllStringSubmatchIndex(input, -1)
+	if len(matches) == 0 {
+		return 0, fmt.Errorf("invalid duration %q", input)
+	}
+
+	var total time.Duration
+	pos := 0
+	for _, match := range matches {
+		// Components must be contiguous, so "1h x" or "h1" are rejected
+		if match[0] != pos {
+			return 0, fmt.Errorf("invalid duration %q", input)
+		}
+		pos = match[1]
+
+		n, err := strconv.Atoi(input[match[2]:match[3]])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", input)
+		}
+
+		var unit time.Duration
+		switch input[match[4]:match[5]] {
+		case "s":
+			unit = time.Second
+		case "m":
+			unit = time.Minute
+		case "h":
+			unit = time.Hour
+		case "d":
+			unit = 24 * time.Hour
+		case "w":
+			unit = 7 * 24 * time.Hour
+		}
+		// Reject values that would overflow and wrap around
+		if int64(n) > (math.MaxInt64-int64(total))/int64(unit) {
+			return 0, fmt.Errorf("duration %q is too long", input)
+		}
+		total += time.Duration(n) * unit
+	}
+
+	if pos != len(input) {
+		return 0, fmt.Errorf("invalid duration %q", input)
+	}
+	if total <= 0 {
+		return 0, fmt.Errorf("duration must be positive")
+	}
+
+	return total, nil
+}
+
+// parseValue converts a single raw value according to spec
+func (c *command) parseValue(ctx *commandContext, spec argSpec, raw string) (any, *argError) {
+	var (
+		value any
+		err   *argError
+	)
+
+	switch spec.Kind {
+	case argUser:
+		value, err = resolveUser(ctx.Session, ctx.GuildID, raw)
+	case argRole:
+		value, err = resolveRole(ctx.Session, ctx.GuildID, raw)
+	case argChannel:
+		value, err = resolveChannel(ctx.Session, ctx.GuildID, raw)
+	case argInt:
+		n, convErr := strconv.Atoi(strings.TrimPrefix(raw, "#"))
+		if convErr != nil || n < 1 {
+			err = &argError{Kind: argErrInvalid, Reason: fmt.Sprintf("`%s` is not a valid %s.", raw, spec.Name)}
+		}
+		value = n
+	case argDuration:
+		d, parseErr := parseDuration(raw)
+		if parseErr != nil {
+			err = &argError{Kind: argErrInvalid, Reason: fmt.Sprintf("Invalid duration `%s`. Use e.g. `30m`, `12h`, `7d` or `1d12h`.", raw)}
+		}
+		value = d
+	case argChoice:
+		choice := strings.ToLower(raw)
+		err = &argError{Kind: argErrInvalid, Reason: fmt.Sprintf("`%s` must be one of: %s.", raw, strings.Join(spec.Choices, ", ")), Usage: c.usageText()}
+		for _, ch := range spec.Choices {
+			if choice == ch {
+				value, err = choice, nil
+				break
+			}
+		}
+	default:
+		value = raw
+		if raw == "" {
+			err = &argError{Kind: argErrInvalid, Reason: fmt.Sprintf("`%s` can't be empty.", spec.Name)}
+		}
+	}
+
+	if err != nil {
+		err.Arg = spec.Name
+		err.Value = raw
+		if spec.Invalid != "" && err.Kind == argErrInvalid {
+			err.Reason = spec.Invalid
+		}
+		return nil, err
+	}
+	return value, nil
+}
+
+// missing is the error for a required argument that wasn't given
+func (c *command) missing(spec argSpec) *argError {
+	return &argError{Kind: argErrMissing, Arg: spec.Name, Usage: c.usageText()}
+}
+
+// parseArgs maps prefix command words onto the command's argument spec
+func (c *command) parseArgs(ctx *commandContext, words []argToken) (commandArgs, error) {
+	args := make(commandArgs)
+	pos := 0
+
+	for i, spec := range c.Args {
+		if spec.Kind == argRest {
+			// Keep the rest verbatim so quotes inside a reason survive; a
+			// single quoted word just loses its quotes
+			if pos == len(words)-1 {
+				args[spec.Name] = words[pos].Value
+				pos = len(words)
+			} else if pos < len(words) {
+				args[spec.Name] = words[pos].Rest
+				pos = len(words)
+			} else if !spec.Optional {
+				return nil, c.missing(spec)
+			} else if spec.Default != "" {
+				args[spec.Name] = spec.Default
+			}
+			continue
+		}
+
+		if pos >= len(words) {
+			if !spec.Optional {
+				return nil, c.missing(spec)
+			}
+			if spec.Default != "" {
+				args[spec.Name] = spec.Default
+			}
+			continue
+		}
+
+		v, err := c.parseValue(ctx, spec, words[pos].Value)
+		if err != nil {
+			// An optional argument that doesn't fit belongs to the next one,
+			// e.g. "!mute @user spamming" has no duration
+			if spec.Optional && i < len(c.Args)-1 {
+				continue
+			}
+			return nil, err
+		}
+
+		args[spec.Name] = v
+		pos++
+	}
+
+	// Without a rest argument to absorb them, leftover words are most likely
+	// a mistake, e.g. an unquoted name or a reason the command doesn't take
+	if pos < len(words) {
+		return nil, &argError{
+			Kind:   argErrInvalid,
+			Value:  words[pos].Rest,
+			Reason: fmt.Sprintf("Unexpected argument `%s`.", words[pos].Rest),
+			Usage:  c.usageText(),
+		}
+	}
+
+	return args, nil
+}
@@ -0,0 +1,345 @@
+package bot
+
+import (
+	"discord-mod-bot/internal/config"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const testGuildID = "100000000000000000"
+
+// withConfig replaces config.Cfg for the duration of a test
+func withConfig(t *testing.T, cfg *config.Config) {
+	t.Helper()
+	previous := config.Cfg
+	config.Cfg = cfg
+	t.Cleanup(func() { config.Cfg = previous })
+}
+
+// testSession returns a session whose state holds a small guild
+func testSession(t *testing.T) *discordgo.Session {
+	t.Helper()
+
+	s := &discordgo.Session{State: discordgo.NewState()}
+	guild := &discordgo.Guild{
+		ID: testGuildID,
+		Roles: []*discordgo.Role{
+			{ID: "200000000000000001", Name: "Moderators"},
+			{ID: "200000000000000002", Name: "Helpers"},
+			{ID: "200000000000000003", Name: "helpers"},
+		},
+		Channels: []*discordgo.Channel{
+			{ID: "300000000000000001", Name: "general"},
+			{ID: "300000000000000002", Name: "media"},
+		},
+		Members: []*discordgo.Member{
+			{User: &discordgo.User{ID: "400000000000000001", Username: "Alice", Discriminator: "0"}},
+			{User: &discordgo.User{ID: "400000000000000002", Username: "bob", Discriminator: "1234"}, Nick: "Cool Guy"},
+			{User: &discordgo.User{ID: "400000000000000003", Username: "bob", Discriminator: "5678"}},
+		},
+	}
+	if err := s.State.GuildAdd(guild); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		input   string
+		values  []string
+		rests   []string
+		wantErr bool
+	}{
+		{input: "", values: nil},
+		{input: "   ", values: nil},
+		{
+			input:  "@user 1h spamming links",
+			values: []string{"@user", "1h", "spamming", "links"},
+			rests:  []string{"@user 1h spamming links", "1h spamming links", "spamming links", "links"},
+		},
+		{
+			input:  `"Cool Guy" 1d  breaking   rules`,
+			values: []string{"Cool Guy", "1d", "breaking", "rules"},
+			rests:  []string{`"Cool Guy" 1d  breaking   rules`, "1d  breaking   rules", "breaking   rules", "rules"},
+		},
+		{input: "“Cool Guy” reason", values: []string{"Cool Guy", "reason"}},
+		{input: `“Cool Guy" reason`, values: []string{"Cool Guy", "reason"}},
+		{input: `"say \"hi\"" now`, values: []string{`say "hi"`, "now"}},
+		{input: `don"t stop`, values: []string{`don"t`, "stop"}},
+		{input: "a\tb\nc", values: []string{"a", "b", "c"}},
+		{input: `""`, values: []string{""}},
+		{input: `"unterminated quote`, wantErr: true},
+		{input: `ok "open`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tokens, err := splitArgs(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("splitArgs(%q) succeeded, want error", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("splitArgs(%q) error: %v", tt.input, err)
+			continue
+		}
+
+		var values, rests []string
+		for _, tok := range tokens {
+			values = append(values, tok.Value)
+			rests = append(rests, tok.Rest)
+		}
+		if !reflect.DeepEqual(values, tt.values) {
+			t.Errorf("splitArgs(%q) = %q, want %q", tt.input, values, tt.values)
+		}
+		if tt.rests != nil && !reflect.DeepEqual(rests, tt.rests) {
+			t.Errorf("splitArgs(%q) rests = %q, want %q", tt.input, rests, tt.rests)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "30s", want: 30 * time.Second},
+		{input: "10m", want: 10 * time.Minute},
+		{input: "2h", want: 2 * time.Hour},
+		{input: "7d", want: 7 * 24 * time.Hour},
+		{input: "1w", want: 7 * 24 * time.Hour},
+		{input: "1d12h", want: 36 * time.Hour},
+		{input: " 1H30M ", want: 90 * time.Minute},
+		{input: "1h1h", want: 2 * time.Hour},
+		{input: "", wantErr: true},
+		{input: "0m", wantErr: true},
+		{input: "10", wantErr: true},
+		{input: "h1", wantErr: true},
+		{input: "1h x", wantErr: true},
+		{input: "1h 30m", wantErr: true},
+		{input: "1y", wantErr: true},
+		{input: "-5m", wantErr: true},
+		{input: "1.5h", wantErr: true},
+		// Values that would overflow and wrap around
+		{input: "9999999999999w", wantErr: true},
+		{input: "15251w", wantErr: true},
+		{input: "9223372036s1s", wantErr: true},
+		{input: "99999999999999999999s", wantErr: true},
+		{input: "15250w", want: 15250 * 7 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		got, err := parseDuration(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseDuration(%q) = %v, want error", tt.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseDuration(%q) error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseDuration(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestResolveUser(t *testing.T) {
+	s := testSession(t)
+
+	tests := []struct {
+		raw      string
+		want     string
+		wantKind argErrorKind
+		wantErr  bool
+	}{
+		{raw: "<@400000000000000001>", want: "400000000000000001"},
+		{raw: "<@!400000000000000001>", want: "400000000000000001"},
+		{raw: "400000000000000001", want: "400000000000000001"},
+		// IDs of users who aren't members anymore are still accepted
+		{raw: "499999999999999999", want: "499999999999999999"},
+		{raw: "alice", want: "400000000000000001"},
+		{raw: "@ALICE", want: "400000000000000001"},
+		{raw: "cool guy", want: "400000000000000002"},
+		{raw: "bob#5678", want: "400000000000000003"},
+		{raw: "bob", wantErr: true, wantKind: argErrAmbiguous},
+		{raw: "bob#0000", wantErr: true, wantKind: argErrInvalid},
+		{raw: "nobody", wantErr: true, wantKind: argErrInvalid},
+		{raw: "12345", wantErr: true, wantKind: argErrInvalid},
+	}
+
+	for _, tt := range tests {
+		got, err := resolveUser(s, testGuildID, tt.raw)
+		if tt.wantErr {
+			if err == nil || err.Kind != tt.wantKind {
+				t.Errorf("resolveUser(%q) = %q, %v; want error kind %d", tt.raw, got, err, tt.wantKind)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("resolveUser(%q) = %q, %v; want %q", tt.raw, got, err, tt.want)
+		}
+	}
+}
+
+func TestResolveRoleAndChannel(t *testing.T) {
+	s := testSession(t)
+
+	roles := []struct {
+		raw      string
+		want     string
+		wantKind argErrorKind
+		wantErr  bool
+	}{
+		{raw: "<@&200000000000000001>", want: "200000000000000001"},
+		{raw: "200000000000000009", want: "200000000000000009"},
+		{raw: "moderators", want: "200000000000000001"},
+		{raw: "@Moderators", want: "200000000000000001"},
+		{raw: "helpers", wantErr: true, wantKind: argErrAmbiguous},
+		{raw: "admins", wantErr: true, wantKind: argErrInvalid},
+	}
+	for _, tt := range roles {
+		got, err := resolveRole(s, testGuildID, tt.raw)
+		if tt.wantErr {
+			if err == nil || err.Kind != tt.wantKind {
+				t.Errorf("resolveRole(%q) = %q, %v; want error kind %d", tt.raw, got, err, tt.wantKind)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("resolveRole(%q) = %q, %v; want %q", tt.raw, got, err, tt.want)
+		}
+	}
+
+	channels := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "<#300000000000000002>", want: "300000000000000002"},
+		{raw: "300000000000000009", want: "300000000000000009"},
+		{raw: "#general", want: "300000000000000001"},
+		{raw: "Media", want: "300000000000000002"},
+		{raw: "#nowhere", wantErr: true},
+	}
+	for _, tt := range channels {
+		got, err := resolveChannel(s, testGuildID, tt.raw)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("resolveChannel(%q) = %q, want error", tt.raw, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("resolveChannel(%q) = %q, %v; want %q", tt.raw, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	withConfig(t, &config.Config{Prefix: "!"})
+	ctx := &commandContext{Session: testSession(t), GuildID: testGuildID}
+
+	mute := &command{
+		Name: "mute",
+		Args: []argSpec{
+			{Name: "user", Kind: argUser},
+			{Name: "duration", Kind: argDuration, Optional: true},
+			reasonArg("Reason"),
+		},
+	}
+	filter := &command{
+		Name: "filter",
+		Args: []argSpec{
+			{Name: "action", Kind: argChoice, Choices: []string{"add", "remove"}},
+			{Name: "value", Kind: argRest, Optional: true},
+		},
+	}
+	caseCmd := &command{
+		Name: "case",
+		Args: []argSpec{{Name: "number", Kind: argInt, Invalid: "Invalid case number."}},
+	}
+
+	tests := []struct {
+		cmd      *command
+		input    string
+		want     commandArgs
+		wantKind argErrorKind
+		wantErr  bool
+	}{
+		{
+			cmd:   mute,
+			input: "@alice 1h spamming links",
+			want:  commandArgs{"user": "400000000000000001", "duration": time.Hour, "reason": "spamming links"},
+		},
+		{
+			// The optional duration is skipped when the word isn't one
+			cmd:   mute,
+			input: `"Cool Guy" spamming`,
+			want:  commandArgs{"user": "400000000000000002", "reason": "spamming"},
+		},
+		{
+			cmd:   mute,
+			input: "<@400000000000000001>",
+			want:  commandArgs{"user": "400000000000000001", "reason": "No reason provided"},
+		},
+		{
+			// Quotes inside a multi-word reason are kept verbatim
+			cmd:   mute,
+			input: `alice 10m said "hi" too often`,
+			want:  commandArgs{"user": "400000000000000001", "duration": 10 * time.Minute, "reason": `said "hi" too often`},
+		},
+		{
+			cmd:   mute,
+			input: `alice "one quoted reason"`,
+			want:  commandArgs{"user": "400000000000000001", "reason": "one quoted reason"},
+		},
+		{cmd: mute, input: "", wantErr: true, wantKind: argErrMissing},
+		{cmd: mute, input: "bob 1h", wantErr: true, wantKind: argErrAmbiguous},
+		{cmd: filter, input: "ADD free nitro", want: commandArgs{"action": "add", "value": "free nitro"}},
+		{cmd: filter, input: "remove", want: commandArgs{"action": "remove"}},
+		{cmd: filter, input: "list", wantErr: true, wantKind: argErrInvalid},
+		{cmd: caseCmd, input: "#12", want: commandArgs{"number": 12}},
+		{cmd: caseCmd, input: "0", wantErr: true, wantKind: argErrInvalid},
+		{cmd: caseCmd, input: "twelve", wantErr: true, wantKind: argErrInvalid},
+		{cmd: caseCmd, input: "12 13", wantErr: true, wantKind: argErrInvalid},
+		{cmd: &command{Name: "ping"}, input: "now", wantErr: true, wantKind: argErrInvalid},
+	}
+
+	for _, tt := range tests {
+		words, err := splitArgs(tt.input)
+		if err != nil {
+			t.Fatalf("splitArgs(%q): %v", tt.input, err)
+		}
+
+		got, err := tt.cmd.parseArgs(ctx, words)
+		if tt.wantErr {
+			argErr, ok := err.(*argError)
+			if !ok || argErr.Kind != tt.wantKind {
+				t.Errorf("%s %q: got %v, %v; want error kind %d", tt.cmd.Name, tt.input, got, err, tt.wantKind)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %q: %v", tt.cmd.Name, tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %q = %v, want %v", tt.cmd.Name, tt.input, got, tt.want)
+		}
+	}
+
+	// spec.Invalid replaces the parser's reason
+	words, _ := splitArgs("twelve")
+	if _, err := caseCmd.parseArgs(ctx, words); err == nil || err.(*argError).Reason != "Invalid case number." {
+		t.Errorf("case twelve: got %v, want the Invalid text", err)
+	}
+}
@@ -0,0 +1,198 @@
+package bot
+
+import (
+	"discord-mod-bot/internal/config"
+	"discord-mod-bot/internal/store"
+	"discord-mod-bot/internal/utils"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// auditLogMaxAge is how old an audit log entry may be and still be matched
+// to a gateway event. Entries are written before the event is dispatched,
+// so anything older belongs to an earlier action.
+const auditLogMaxAge = 15 * time.Second
+
+// findAuditEntry returns the newest recent audit log entry of the given
+// type whose target is targetID, or nil if there is none (e.g. a member
+// left on their own instead of being kicked)
+func findAuditEntry(s *discordgo.Session, guildID, targetID string, action discordgo.AuditLogAction) *discordgo.AuditLogEntry {
+	auditLog, err := s.GuildAuditLog(guildID, "", "", int(action), 10)
+	if err != nil {
+		log.Printf("Audit: Error fetching audit log (action %d): %v", action, err)
+		return nil
+	}
+
+	for _, entry := range auditLog.AuditLogEntries {
+		if entry.TargetID != targetID {
+			continue
+		}
+
+		created, err := discordgo.SnowflakeTimestamp(entry.ID)
+		if err != nil || time.Since(created) > auditLogMaxAge {
+			continue
+		}
+
+		return entry
+	}
+
+	return nil
+}
+
+// externalAction reports whether an audit log entry was made by someone in
+// the Discord client rather than by the bot, whose own actions already have
+// cases
+func externalAction(s *discordgo.Session, entry *discordgo.AuditLogEntry) bool {
+	return entry != nil && entry.UserID != "" && entry.UserID != botUserID(s)
+}
+
+// countExternalAction counts an action taken in the Discord client against
+// the moderator's quota. It can't be prevented anymore, so going over the
+// limit is only noted in the log.
+func countExternalAction(s *discordgo.Session, guildID, moderatorID, action string) string {
+	err := utils.CountModAction(s, guildID, moderatorID, action)
+
+	var quotaErr *utils.QuotaExceededError
+	if errors.As(err, &quotaErr) {
+		log.Printf("Audit: %s by %s exceeded their %s quota (%s)", capitalize(action), moderatorID, action, quotaErr.Limit)
+		return fmt.Sprintf("⚠️ Over the %s quota (%s)", action, quotaErr.Limit)
+	}
+	return ""
+}
+
+// recordExternalCase records and logs a case for an action taken in the
+// Discord client, the same way bot-issued actions are
+func (b *Bot) recordExternalCase(s *discordgo.Session, guildID, action string, entry *discordgo.AuditLogEntry, quotaAction string) {
+	reason := entry.Reason
+	if quotaAction != "" {
+		if note := countExternalAction(s, guildID, entry.UserID, quotaAction); note != "" {
+			if reason == "" {
+				reason = "No reason provided"
+			}
+			reason += "\n" + note
+		}
+	}
+
+	log.Printf("Audit: %s of user %s by %s made outside the bot", action, entry.TargetID, entry.UserID)
+	c := b.recordCase(guildID, action, entry.UserID, entry.TargetID, reason, time.Time{})
+	b.logCase(s, c)
+}
+
+// onGuildBanAdd records bans made outside the bot and feeds the anti-nuke
+// watchdog
+func (b *Bot) onGuildBanAdd(s *discordgo.Session, e *discordgo.GuildBanAdd) {
+	if e.GuildID != config.Cfg.GuildID || e.User == nil {
+		return
+	}
+
+	entry := findAuditEntry(s, e.GuildID, e.User.ID, discordgo.AuditLogActionMemberBanAdd)
+	if !externalAction(s, entry) {
+		return
+	}
+
+	b.trackDestructiveAction(s, e.GuildID, entry.UserID, nukeActionBan)
+
+	// A ban in the client is permanent, so drop any scheduled unban
+	b.cancelExpiry(expiryActionUnban, e.GuildID, e.User.ID)
+	b.recordExternalCase(s, e.GuildID, store.ActionBan, entry, utils.ActionBan)
+}
+
+// onGuildBanRemove records unbans made outside the bot
+func (b *Bot) onGuildBanRemove(s *discordgo.Session, e *discordgo.GuildBanRemove) {
+	if e.GuildID != config.Cfg.GuildID || e.User == nil {
+		return
+	}
+
+	entry := findAuditEntry(s, e.GuildID, e.User.ID, discordgo.AuditLogActionMemberBanRemove)
+	if !externalAction(s, entry) {
+		return
+	}
+
+	b.cancelExpiry(expiryActionUnban, e.GuildID, e.User.ID)
+	b.recordExternalCase(s, e.GuildID, store.ActionUnban, entry, "")
+}
+
+// reconcileKick records a kick made outside the bot. Members who left on
+// their own have no audit log entry.
+func (b *Bot) reconcileKick(s *discordgo.Session, guildID, userID string) {
+	entry := findAuditEntry(s, guildID, userID, discordgo.AuditLogActionMemberKick)
+	if !externalAction(s, entry) {
+		return
+	}
+
+	b.trackDestructiveAction(s, guildID, entry.UserID, nukeActionKick)
+	b.recordExternalCase(s, guildID, store.ActionKick, entry, utils.ActionKick)
+}
+
+// managedRole holds the role log entries of a role the bot manages
+type managedRole struct {
+	added, removed loggedAction
+}
+
+// managedRoles returns the mod, staff and vanity roles by role ID
+func managedRoles() map[string]managedRole {
+	roles := make(map[string]managedRole)
+	if config.Cfg.ModRoleID != "" {
+		roles[config.Cfg.ModRoleID] = managedRole{logModRoleAdded, logModRoleRemoved}
+	}
+	if config.Cfg.StaffRoleID != "" {
+		roles[config.Cfg.StaffRoleID] = managedRole{logStaffRoleAdded, logStaffRoleRemoved}
+	}
+	if config.Cfg.VanityRoleID != "" {
+		roles[config.Cfg.VanityRoleID] = managedRole{logVanityRoleAdded, logVanityRoleRemoved}
+	}
+	return roles
+}
+
+// reconcileRoles records role changes made outside the bot: the mute role
+// becomes a mute or unmute case, managed roles get their usual role log
+// entry and anything else is logged as a role update
+func (b *Bot) reconcileRoles(s *discordgo.Session, guildID, userID string, added, removed []string) {
+	entry := findAuditEntry(s, guildID, userID, discordgo.AuditLogActionMemberRoleUpdate)
+	if !externalAction(s, entry) {
+		return
+	}
+
+	if len(removed) > 0 {
+		b.trackDestructiveAction(s, guildID, entry.UserID, nukeActionRoleRemove)
+	}
+
+	managed := managedRoles()
+	var other []string
+
+	for _, roleID := range added {
+		if roleID == config.Cfg.MuteRoleID {
+			// A mute in the client has no duration
+			b.cancelExpiry(expiryActionUnmute, guildID, userID)
+			b.recordExternalCase(s, guildID, store.ActionMute, entry, utils.ActionMute)
+		} else if role, ok := managed[roleID]; ok {
+			b.logAction(s, role.added, entry.UserID, userID, entry.Reason)
+		} else {
+			other = append(other, fmt.Sprintf("➕ <@&%s>", roleID))
+		}
+	}
+
+	for _, roleID := range removed {
+		if roleID == config.Cfg.MuteRoleID {
+			b.cancelExpiry(expiryActionUnmute, guildID, userID)
+			b.recordExternalCase(s, guildID, store.ActionUnmute, entry, "")
+		} else if role, ok := managed[roleID]; ok {
+			b.logAction(s, role.removed, entry.UserID, userID, entry.Reason)
+		} else {
+			other = append(other, fmt.Sprintf("➖ <@&%s>", roleID))
+		}
+	}
+
+	if len(other) > 0 {
+		changes := strings.Join(other, "\n")
+		if entry.Reason != "" {
+			changes += "\n\n" + entry.Reason
+		}
+		b.logAction(s, logRolesUpdated, entry.UserID, userID, changes)
+	}
+}
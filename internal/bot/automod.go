@@ -0,0 +1,258 @@
+package bot
+
+import (
+	"discord-mod-bot/internal/config"
+	"discord-mod-bot/internal/utils"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Automod actions, applied to the author after their messages are deleted
+const (
+	automodDelete  = "delete"  // only delete
+	automodWarn    = "warn"    // warning, with the usual escalation
+	automodTimeout = "timeout" // Discord timeout
+	automodMute    = "mute"    // MUTE_ROLE_ID
+	automodKick    = "kick"
+
+	automodQuarantine = "quarantine" // QUARANTINE_ROLE_ID, or a 28 day timeout
+)
+
+// automodAction is what an automod rule does to the author
+type automodAction struct {
+	Kind     string
+	Duration time.Duration // timeout and mute only; 0 mutes permanently
+}
+
+// parseAutomodAction parses an action setting like SPAM_ACTION with its
+// duration setting
+func parseAutomodAction(kind, duration string) (automodAction, error) {
+	action := automodAction{Kind: strings.ToLower(strings.TrimSpace(kind))}
+
+	switch action.Kind {
+	case automodDelete, automodWarn, automodKick, automodQuarantine:
+		return action, nil
+	case automodTimeout, automodMute:
+	default:
+		return action, fmt.Errorf("invalid action %q (use %s, %s, %s, %s, %s or %s)", kind,
+			automodDelete, automodWarn, automodTimeout, automodMute, automodKick, automodQuarantine)
+	}
+
+	if action.Kind == automodMute && config.Cfg.MuteRoleID == "" {
+		return action, fmt.Errorf("action %s needs MUTE_ROLE_ID", automodMute)
+	}
+
+	if strings.TrimSpace(duration) == "" {
+		if action.Kind == automodTimeout {
+			return action, fmt.Errorf("action %s needs a duration", automodTimeout)
+		}
+		return action, nil
+	}
+
+	d, err := parseDuration(duration)
+	if err != nil {
+		return action, err
+	}
+	if action.Kind == automodTimeout && d > maxTimeout {
+		return action, fmt.Errorf("timeouts can be at most 28 days")
+	}
+	action.Duration = d
+	return action, nil
+}
+
+func (a automodAction) String() string {
+	switch a.Kind {
+	case automodTimeout:
+		return "timeout for " + formatDuration(a.Duration)
+	case automodMute:
+		if a.Duration > 0 {
+			return "mute for " + formatDuration(a.Duration)
+		}
+		return "permanent mute"
+	default:
+		return a.Kind
+	}
+}
+
+// messageRef points to a message to delete
+type messageRef struct {
+	ChannelID string
+	ID        string
+}
+
+// automodViolation is a message, or burst of messages, that broke a rule
+type automodViolation struct {
+	Rule     string // short rule name, e.g. "Spam"
+	Reason   string // what happened; becomes the case reason
+	Detail   string // shown in the log only, e.g. the matched rule
+	Action   automodAction
+	Messages []messageRef // messages to delete; the triggering one if empty
+}
+
+// automodCheck inspects a message and returns the rule it broke, or nil
+type automodCheck struct {
+	Check      func(b *Bot, s *discordgo.Session, m *discordgo.Message) *automodViolation
+	Edits      bool // also run when a message is edited
+	Moderators bool // also run for moderators, whose accounts can be compromised too
+}
+
+// automodChecks run in order on every message; the first violation wins
+var automodChecks = []automodCheck{
+	{Check: (*Bot).checkPhishing, Edits: true, Moderators: true},
+	{Check: (*Bot).checkBlocklist, Edits: true},
+	{Check: (*Bot).checkLinks, Edits: true},
+	// Exempt by MENTION_EXEMPT_ROLES instead; edits don't ping anyone
+	{Check: (*Bot).checkMentions, Moderators: true},
+	{Check: (*Bot).checkSpam},
+}
+
+// runAutomod checks a new or edited message against the automod rules and
+// returns true if it was removed
+func (b *Bot) runAutomod(s *discordgo.Session, m *discordgo.Message, edited bool) bool {
+	if !config.Cfg.AutomodEnabled || m.GuildID != config.Cfg.GuildID || m.Author == nil {
+		return false
+	}
+
+	// Moderators are trusted with most rules
+	moderator := hasAnyModRole(s, m.GuildID, m.Author.ID)
+
+	for _, check := range automodChecks {
+		if (edited && !check.Edits) || (moderator && !check.Moderators) {
+			continue
+		}
+		if v := check.Check(b, s, m); v != nil {
+			b.enforceAutomod(s, m, v)
+			return true
+		}
+	}
+	return false
+}
+
+// enforceAutomod deletes the offending messages, applies the rule's action
+// and logs the violation
+func (b *Bot) enforceAutomod(s *discordgo.Session, m *discordgo.Message, v *automodViolation) {
+	refs := v.Messages
+	if len(refs) == 0 {
+		refs = []messageRef{{ChannelID: m.ChannelID, ID: m.ID}}
+	}
+	deleted := b.deleteMessages(s, refs)
+
+	log.Printf("AutoMod: %s by user %s in %s: %s", v.Rule, m.Author.ID, m.ChannelID, v.Reason)
+
+	result := b.applyAutomodAction(s, m.GuildID, m.Author.ID, v)
+	b.logAutomod(s, m.Author, v, result,
+		&discordgo.MessageEmbedField{Name: "Channel", Value: fmt.Sprintf("<#%s>", m.ChannelID), Inline: true},
+		&discordgo.MessageEmbedField{Name: "Deleted", Value: fmt.Sprintf("%d message(s)", deleted), Inline: true},
+		contentField("Message", m.Content),
+	)
+}
+
+// deleteMessages removes refs, grouped by channel, and returns how many
+// were deleted. They are logged by automod, not the message log.
+func (b *Bot) deleteMessages(s *discordgo.Session, refs []messageRef) int {
+	byChannel := make(map[string][]string)
+	var channels []string
+	for _, ref := range refs {
+		if byChannel[ref.ChannelID] == nil {
+			channels = append(channels, ref.ChannelID)
+		}
+		byChannel[ref.ChannelID] = append(byChannel[ref.ChannelID], ref.ID)
+	}
+
+	deleted := 0
+	for _, channelID := range channels {
+		ids := byChannel[channelID]
+		b.messages.markPurged(ids)
+
+		for start := 0; start < len(ids); start += bulkDeleteBatch {
+			end := start + bulkDeleteBatch
+			if end > len(ids) {
+				end = len(ids)
+			}
+			if err := s.ChannelMessagesBulkDelete(channelID, ids[start:end]); err != nil {
+				log.Printf("AutoMod: Error deleting messages in %s: %v", channelID, err)
+				break
+			}
+			deleted += end - start
+		}
+	}
+	return deleted
+}
+
+// applyAutomodAction punishes userID for v and describes the outcome
+func (b *Bot) applyAutomodAction(s *discordgo.Session, guildID, userID string, v *automodViolation) string {
+	action := v.Action
+	if action.Kind == automodDelete || action.Kind == "" {
+		return "Message deleted"
+	}
+
+	moderatorID := botUserID(s)
+	if err := utils.CheckHierarchy(s, guildID, moderatorID, userID); err != nil {
+		log.Printf("AutoMod: Skipping %s for user %s: %v", action.Kind, userID, err)
+		return fmt.Sprintf("⚠️ %s skipped: %v", capitalize(action.String()), err)
+	}
+
+	reason := fmt.Sprintf("AutoMod (%s): %s", v.Rule, v.Reason)
+
+	var err error
+	var result string
+	switch action.Kind {
+	case automodWarn:
+		var escalation string
+		_, _, escalation, err = b.warnMember(s, guildID, moderatorID, userID, reason)
+		result = "Warned"
+		if escalation != "" {
+			result += "\n" + escalation
+		}
+	case automodTimeout:
+		_, err = b.timeoutMember(s, guildID, moderatorID, userID, reason, action.Duration)
+		result = "Timed out for " + formatDuration(action.Duration)
+	case automodMute:
+		_, err = b.roleMuteMember(s, guildID, moderatorID, userID, reason, action.Duration)
+		result = "Muted permanently"
+		if action.Duration > 0 {
+			result = "Muted for " + formatDuration(action.Duration)
+		}
+	case automodKick:
+		_, err = b.kickMember(s, guildID, moderatorID, userID, reason)
+		result = "Kicked"
+	case automodQuarantine:
+		_, err = b.quarantineMember(s, guildID, moderatorID, userID, reason)
+		result = "Quarantined"
+	}
+
+	if err != nil {
+		log.Printf("AutoMod: Error applying %s to user %s: %v", action, userID, err)
+		return fmt.Sprintf("⚠️ %s failed: %v", capitalize(action.String()), err)
+	}
+	return result
+}
+
+// logAutomod posts a violation by user to the automod log channel. fields
+// describe where it happened.
+func (b *Bot) logAutomod(s *discordgo.Session, user *discordgo.User, v *automodViolation, result string, fields ...*discordgo.MessageEmbedField) {
+	if logChannel(logAutomod) == "" {
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "🛡️ AutoMod: " + v.Rule,
+		Description: v.Reason,
+		Color:       colorOrange,
+		Fields:      append([]*discordgo.MessageEmbedField{userField("User", user.ID, user)}, fields...),
+		Footer:      &discordgo.MessageEmbedFooter{Text: "User ID: " + user.ID},
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+	if v.Detail != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Rule", Value: truncate(v.Detail, 1024), Inline: false})
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Action", Value: result, Inline: false})
+
+	if _, err := sendLog(s, logAutomod, &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}}); err != nil {
+		log.Printf("AutoMod: Error sending log message: %v", err)
+	}
+}
@@ -2,8 +2,11 @@ package bot
 
 import (
 	"discord-mod-bot/internal/config"
+	"discord-mod-bot/internal/store"
+	"discord-mod-bot/internal/utils"
 	"fmt"
 	"log"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -16,6 +19,26 @@ type Bot struct {
 	vanityCooldowns   map[string]time.Time
 	vanityCooldownMux sync.RWMutex
 	startupChecked    bool
+	scheduler         *scheduler
+	store             store.Store
+	escalationRules   []escalationRule
+	nukeWatchdog      *nukeWatchdog
+	commands          *commandRegistry
+	confirmations     *confirmations
+	messages          *messageCache
+	members           *memberTracker
+	spam              *spamTracker
+	spamAction        automodAction
+	blocklist         *blocklist
+	filterAction      automodAction
+	linkRules         map[string]*linkRule
+	linkAction        automodAction
+	invites           *inviteCache
+	phishing          *phishingList // nil without PHISHING_DOMAINS_FILE
+	phishingAction    automodAction
+	mentions          *mentionTracker
+	mentionAction     automodAction
+	mentionExempt     []string // role IDs
 }
 
 func New() (*Bot, error) {
@@ -24,12 +47,113 @@ func New() (*Bot, error) {
 		return nil, fmt.Errorf("error creating Discord session: %w", err)
 	}
 
-	session.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildMembers | discordgo.IntentsGuildMessages | discordgo.IntentsGuildPresences | discordgo.IntentsMessageContent
+	caseStore, err := store.Open(filepath.Join(config.Cfg.DataDir, "store.json"))
+	if err != nil {
+		return nil, fmt.Errorf("error opening store: %w", err)
+	}
+
+	// Keep mod daily limits in the store so a restart doesn't reset them
+	utils.SetModActionCounter(caseStore)
+
+	if config.Cfg.ModQuotas != "" {
+		policy, err := utils.ParseQuotaPolicy(config.Cfg.ModQuotas)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing MOD_QUOTAS: %w", err)
+		}
+		utils.SetQuotaPolicy(policy)
+	}
+
+	if config.Cfg.MuteMode != muteModeRole && config.Cfg.MuteMode != muteModeTimeout {
+		return nil, fmt.Errorf("invalid MUTE_MODE %q (use %s or %s)", config.Cfg.MuteMode, muteModeRole, muteModeTimeout)
+	}
+
+	escalationRules, err := parseEscalationRules(config.Cfg.WarnEscalation)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing WARN_ESCALATION: %w", err)
+	}
+
+	spamAction, err := parseAutomodAction(config.Cfg.SpamAction, config.Cfg.SpamActionDuration)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing SPAM_ACTION: %w", err)
+	}
+
+	filterAction, err := parseAutomodAction(config.Cfg.FilterAction, config.Cfg.FilterActionDuration)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing FILTER_ACTION: %w", err)
+	}
+
+	linkRules, err := parseLinkRules(config.Cfg.LinkDomainRules)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing LINK_DOMAIN_RULES: %w", err)
+	}
+
+	linkAction, err := parseAutomodAction(config.Cfg.LinkAction, config.Cfg.LinkActionDuration)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing LINK_ACTION: %w", err)
+	}
+
+	phishingAction, err := parseAutomodAction(config.Cfg.PhishingAction, config.Cfg.PhishingActionDuration)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing PHISHING_ACTION: %w", err)
+	}
+
+	mentionAction, err := parseAutomodAction(config.Cfg.MentionAction, config.Cfg.MentionActionDuration)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing MENTION_ACTION: %w", err)
+	}
+
+	mentionExempt, err := parseMentionExempt(config.Cfg.MentionExemptRoles)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing MENTION_EXEMPT_ROLES: %w", err)
+	}
+
+	var phishing *phishingList
+	if config.Cfg.PhishingDomainsFile != "" {
+		phishing = newPhishingList(config.Cfg.PhishingDomainsFile)
+		if err := phishing.reload(true); err != nil {
+			log.Printf("Phishing: Error loading domain list: %v", err)
+		}
+	}
+
+	var messageCachePath string
+	if config.Cfg.MessageCachePersist {
+		messageCachePath = filepath.Join(config.Cfg.DataDir, messageCacheFile)
+	}
+	messages := newMessageCache(config.Cfg.MessageCacheSize, messageCachePath)
+	if err := messages.load(); err != nil {
+		log.Printf("MessageLog: Error loading message cache: %v", err)
+	}
+
+	session.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildMembers | discordgo.IntentsGuildBans | discordgo.IntentsGuildMessages | discordgo.IntentsGuildPresences | discordgo.IntentsMessageContent
 
 	bot := &Bot{
 		Session:         session,
 		vanityCooldowns: make(map[string]time.Time),
 		startupChecked:  false,
+		scheduler:       newScheduler(config.Cfg.DataDir),
+		store:           caseStore,
+		escalationRules: escalationRules,
+		nukeWatchdog:    newNukeWatchdog(),
+		commands:        newCommandRegistry(defaultCommands()),
+		confirmations:   newConfirmations(),
+		messages:        messages,
+		members:         newMemberTracker(),
+		spam:            newSpamTracker(),
+		spamAction:      spamAction,
+		blocklist:       &blocklist{},
+		filterAction:    filterAction,
+		linkRules:       linkRules,
+		linkAction:      linkAction,
+		invites:         newInviteCache(),
+		phishing:        phishing,
+		phishingAction:  phishingAction,
+		mentions:        newMentionTracker(),
+		mentionAction:   mentionAction,
+		mentionExempt:   mentionExempt,
+	}
+
+	if err := bot.loadBlocklist(); err != nil {
+		return nil, fmt.Errorf("error loading filter rules: %w", err)
 	}
 
 	return bot, nil
@@ -39,7 +163,19 @@ func (b *Bot) Start() error {
 	// Register handlers
 	b.Session.AddHandler(b.onReady)
 	b.Session.AddHandler(b.onMessageCreate)
+	b.Session.AddHandler(b.onInteractionCreate)
 	b.Session.AddHandler(b.onPresenceUpdate)
+	b.Session.AddHandler(b.onGuildCreate)
+	b.Session.AddHandler(b.onGuildMembersChunk)
+	b.Session.AddHandler(b.onGuildBanAdd)
+	b.Session.AddHandler(b.onGuildBanRemove)
+	b.Session.AddHandler(b.onGuildMemberAdd)
+	b.Session.AddHandler(b.onGuildMemberRemove)
+	b.Session.AddHandler(b.onGuildMemberUpdate)
+	b.Session.AddHandler(b.onChannelDelete)
+	b.Session.AddHandler(b.onMessageDelete)
+	b.Session.AddHandler(b.onMessageDeleteBulk)
+	b.Session.AddHandler(b.onMessageUpdate)
 
 	// Open connection
 	if err := b.Session.Open(); err != nil {
@@ -51,6 +187,13 @@ func (b *Bot) Start() error {
 }
 
 func (b *Bot) Stop() error {
+	b.scheduler.stop()
+	if err := b.messages.save(); err != nil {
+		log.Printf("MessageLog: Error saving message cache: %v", err)
+	}
+	if err := b.store.Close(); err != nil {
+		log.Printf("Error closing store: %v", err)
+	}
 	return b.Session.Close()
 }
 
@@ -69,8 +212,18 @@ func (b *Bot) onReady(s *discordgo.Session, event *discordgo.Ready) {
 	log.Printf("  - Mod Role ID: %s", config.Cfg.ModRoleID)
 	log.Printf("  - Staff Role ID: %s", config.Cfg.StaffRoleID)
 	log.Printf("  - Message Content Intent: Enabled")
+	log.Printf("  - Anti-Nuke: %v (threshold %d in %ds)", config.Cfg.AntiNukeEnabled, config.Cfg.AntiNukeThreshold, config.Cfg.AntiNukeWindow)
 	log.Printf("Use '%s' as prefix for commands (e.g., %sban @user)", config.Cfg.Prefix, config.Cfg.Prefix)
 
+	// Slash commands share their logic with the prefix commands
+	b.registerSlashCommands(s)
+
+	// Re-arm timed mutes and bans persisted before the last restart
+	b.loadExpiries()
+
+	// Drop mod actions that no quota window covers anymore
+	utils.CleanupOldCounts()
+
 	// Check all members for vanity status on startup
 	if config.Cfg.VanityEnabled && !b.startupChecked {
 		b.startupChecked = true
@@ -91,11 +244,18 @@ func (b *Bot) onMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate)
 		return
 	}
 
+	b.cacheMessage(m.Message)
+
 	// Ignore messages from bots
 	if m.Author.Bot {
 		return
 	}
 
+	// Removed messages aren't processed any further
+	if b.runAutomod(s, m.Message, false) {
+		return
+	}
+
 	// Check if message is in auto-nick channel and handle auto-nickname
 	if config.Cfg.AutoNickChannelID != "" && m.ChannelID == config.Cfg.AutoNickChannelID {
 		b.handleAutoNickname(s, m)
@@ -0,0 +1,267 @@
+package bot
+
+import (
+	"discord-mod-bot/internal/config"
+	"discord-mod-bot/internal/store"
+	"discord-mod-bot/internal/utils"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// casesPerPage is how many cases !cases shows per page
+const casesPerPage = 10
+
+// caseLabels are the log headers used for each case action
+var caseLabels = map[string]string{
+	store.ActionBan:     "🔨 **Ban**",
+	store.ActionTempban: "⏳ **Temp Ban**",
+	store.ActionKick:    "👢 **Kick**",
+	store.ActionMute:    "🔇 **Mute**",
+	store.ActionUnban:   "✅ **Unban**",
+	store.ActionUnmute:  "🔊 **Unmute**",
+	store.ActionWarn:    "⚠️ **Warn**",
+
+	store.ActionQuarantine: "🚧 **Quarantine**",
+}
+
+// caseLabel returns the log header for a case action
+func caseLabel(action string) string {
+	if label, ok := caseLabels[action]; ok {
+		return label
+	}
+	if action == "" {
+		return "**Unknown**"
+	}
+	return "**" + capitalize(action) + "**"
+}
+
+// recordCase stores a moderation action as a new numbered case.
+// If the case could not be saved the returned case has ID 0; the action
+// itself has already happened at that point so callers only lose the
+// audit record.
+func (b *Bot) recordCase(guildID, action, moderatorID, targetID, reason string, expiresAt time.Time) *store.Case {
+	c := &store.Case{
+		GuildID:     guildID,
+		Action:      action,
+		ModeratorID: moderatorID,
+		TargetID:    targetID,
+		Reason:      reason,
+		ExpiresAt:   expiresAt,
+	}
+
+	if err := b.store.CreateCase(c); err != nil {
+		log.Printf("Cases: Error recording %s case for user %s: %v", action, targetID, err)
+		c.ID = 0
+		return c
+	}
+
+	log.Printf("Cases: Recorded case #%d (%s) against user %s by %s", c.ID, action, targetID, moderatorID)
+	return c
+}
+
+// caseSuffix returns " (Case #N)" for replies, or "" if the case wasn't saved
+func caseSuffix(c *store.Case) string {
+	if c == nil || c.ID == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (Case #%d)", c.ID)
+}
+
+// logCase posts a case to the moderation log channel and remembers the
+// message so !reason can edit it later
+func (b *Bot) logCase(s *discordgo.Session, c *store.Case) {
+	msg, err := sendLog(s, logModeration, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{caseLogEmbed(s, c)},
+	})
+	if err != nil {
+		log.Printf("Error sending log message: %v", err)
+		return
+	}
+
+	if msg == nil || c.ID == 0 {
+		return // No log channel configured, or nothing to link it to
+	}
+
+	c.LogChannelID = msg.ChannelID
+	c.LogMessageID = msg.ID
+	if err := b.store.UpdateCase(c); err != nil {
+		log.Printf("Cases: Error saving log message for case #%d: %v", c.ID, err)
+	}
+}
+
+// hasAnyModRole reports whether the user is admin, staff or mod
+func hasAnyModRole(s *discordgo.Session, guildID, userID string) bool {
+	hasAdmin, _ := utils.HasPermission(s, guildID, userID, utils.RoleAdmin)
+	hasMod, _ := utils.HasPermission(s, guildID, userID, utils.RoleMod)
+	hasStaff, _ := utils.HasPermission(s, guildID, userID, utils.RoleStaff)
+	return hasAdmin || hasMod || hasStaff
+}
+
+// caseEmbed renders a single case for !case
+func caseEmbed(c *store.Case) *discordgo.MessageEmbed {
+	reason := c.Reason
+	if reason == "" {
+		reason = "No reason provided"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:     fmt.Sprintf("Case #%d | %s", c.ID, strings.Trim(caseLabel(c.Action), "* ")),
+		Color:     0x5865F2,
+		Timestamp: c.CreatedAt.Format(time.RFC3339),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Target", Value: fmt.Sprintf("<@%s> (`%s`)", c.TargetID, c.TargetID), Inline: true},
+			{Name: "Moderator", Value: fmt.Sprintf("<@%s>", c.ModeratorID), Inline: true},
+			{Name: "Reason", Value: truncate(reason, 1024), Inline: false},
+		},
+	}
+
+	if !c.ExpiresAt.IsZero() {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Expires",
+			Value:  fmt.Sprintf("<t:%d:f> (<t:%d:R>)", c.ExpiresAt.Unix(), c.ExpiresAt.Unix()),
+			Inline: false,
+		})
+	}
+
+	if c.LogChannelID != "" && c.LogMessageID != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Log Message",
+			Value:  fmt.Sprintf("https://discord.com/channels/%s/%s/%s", c.GuildID, c.LogChannelID, c.LogMessageID),
+			Inline: false,
+		})
+	}
+
+	return embed
+}
+
+func (b *Bot) handleCase(ctx *commandContext, args commandArgs) {
+	id := args.Int("number")
+
+	c, err := b.store.Case(id)
+	if errors.Is(err, store.ErrNotFound) {
+		ctx.Reply(fmt.Sprintf("❌ Case #%d not found.", id))
+		return
+	}
+	if err != nil {
+		log.Printf("Cases: Error loading case #%d: %v", id, err)
+		ctx.Reply("❌ Failed to load case.")
+		return
+	}
+
+	ctx.ReplyEmbed(caseEmbed(c))
+}
+
+func (b *Bot) handleCases(ctx *commandContext, args commandArgs) {
+	userID := args.User("user")
+
+	page := 1
+	if args.Has("page") {
+		page = args.Int("page")
+	}
+
+	cases, err := b.store.CasesForUser(userID)
+	if err != nil {
+		log.Printf("Cases: Error loading cases for user %s: %v", userID, err)
+		ctx.Reply("❌ Failed to load cases.")
+		return
+	}
+
+	if len(cases) == 0 {
+		ctx.Reply(fmt.Sprintf("No cases found for <@%s>.", userID))
+		return
+	}
+
+	totalPages := (len(cases) + casesPerPage - 1) / casesPerPage
+	if page > totalPages {
+		ctx.Reply(fmt.Sprintf("❌ Page %d doesn't exist (%d pages).", page, totalPages))
+		return
+	}
+
+	// Newest first
+	start := len(cases) - page*casesPerPage
+	end := start + casesPerPage
+	if start < 0 {
+		start = 0
+	}
+
+	var lines []string
+	for i := end - 1; i >= start; i-- {
+		c := cases[i]
+		reason := c.Reason
+		if reason == "" {
+			reason = "No reason provided"
+		}
+		if r := []rune(reason); len(r) > 80 {
+			reason = string(r[:77]) + "..."
+		}
+		lines = append(lines, fmt.Sprintf("`#%d` %s <t:%d:d> by <@%s>\n└ %s",
+			c.ID, caseLabel(c.Action), c.CreatedAt.Unix(), c.ModeratorID, reason))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Cases for %s", userID),
+		Description: fmt.Sprintf("<@%s> has **%d** case(s)\n\n%s", userID, len(cases), strings.Join(lines, "\n")),
+		Color:       0x5865F2,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Page %d/%d • %scases @user <page>", page, totalPages, config.Cfg.Prefix),
+		},
+	}
+
+	ctx.ReplyEmbed(embed)
+}
+
+func (b *Bot) handleReason(ctx *commandContext, args commandArgs) {
+	s := ctx.Session
+	id := args.Int("number")
+
+	c, err := b.store.Case(id)
+	if errors.Is(err, store.ErrNotFound) {
+		ctx.Reply(fmt.Sprintf("❌ Case #%d not found.", id))
+		return
+	}
+	if err != nil {
+		log.Printf("Cases: Error loading case #%d: %v", id, err)
+		ctx.Reply("❌ Failed to load case.")
+		return
+	}
+
+	// Mods may only amend their own cases; admin/staff can amend any
+	if c.ModeratorID != ctx.Author.ID {
+		hasAdmin, _ := utils.HasPermission(s, ctx.GuildID, ctx.Author.ID, utils.RoleAdmin)
+		hasStaff, _ := utils.HasPermission(s, ctx.GuildID, ctx.Author.ID, utils.RoleStaff)
+		if !hasAdmin && !hasStaff {
+			ctx.Reply("❌ You can only change the reason of your own cases. (Admin/Staff can change any)")
+			return
+		}
+	}
+
+	c.Reason = args.String("reason")
+	if err := b.store.UpdateCase(c); err != nil {
+		log.Printf("Cases: Error updating case #%d: %v", id, err)
+		ctx.Reply("❌ Failed to update case.")
+		return
+	}
+
+	// Keep the original log message in sync
+	if c.LogChannelID != "" && c.LogMessageID != "" {
+		// Older logs were plain text, so clear the content as well
+		content := ""
+		_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:      c.LogMessageID,
+			Channel: c.LogChannelID,
+			Content: &content,
+			Embeds:  []*discordgo.MessageEmbed{caseLogEmbed(s, c)},
+		})
+		if err != nil {
+			log.Printf("Cases: Error editing log message for case #%d: %v", id, err)
+		}
+	}
+
+	log.Printf("Cases: Reason of case #%d updated by %s", id, ctx.Author.ID)
+	ctx.Reply(fmt.Sprintf("✅ Updated reason of case #%d: %s", id, c.Reason))
+}
@@ -2,371 +2,510 @@ package bot
 
 import (
 	"discord-mod-bot/internal/config"
+	"discord-mod-bot/internal/store"
 	"discord-mod-bot/internal/utils"
+	"errors"
 	"fmt"
 	"log"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
 
+// Help categories, in the order !help lists them
+const (
+	categoryModeration = "📋 Moderation"
+	categoryRoles      = "👥 Role Management"
+	categoryRecords    = "📁 Warnings & Cases"
+	categoryAutomod    = "🛡️ AutoMod"
+	categoryUser       = "👤 User Commands"
+)
+
+var helpCategories = []string{categoryModeration, categoryRoles, categoryRecords, categoryAutomod, categoryUser}
+
+// reasonArg is the optional trailing reason shared by moderation commands
+func reasonArg(description string) argSpec {
+	return argSpec{Name: "reason", Kind: argRest, Optional: true, Default: "No reason provided", Description: description}
+}
+
+// defaultCommands is the full command set. Adding a command here is all it
+// takes for prefix dispatch, !help and (with Slash set) slash commands.
+func defaultCommands() []*command {
+	return []*command{
+		{
+			Name:        "ban",
+			Category:    categoryModeration,
+			Tier:        tierMod,
+			Args:        []argSpec{{Name: "user", Kind: argUser, Description: "User to ban"}, reasonArg("Reason for the ban")},
+			Description: "Permanently ban a user (counts towards your quota)",
+			Slash:       true,
+			Confirm:     true,
+			Handler:     (*Bot).handleBan,
+		},
+		{
+			Name:     "tempban",
+			Category: categoryModeration,
+			Tier:     tierMod,
+			Args: []argSpec{
+				{Name: "user", Kind: argUser, Description: "User to ban"},
+				{Name: "duration", Kind: argDuration, Description: "How long, e.g. 7d or 1d12h"},
+				reasonArg("Reason for the ban"),
+			},
+			Description: "Ban a user and unban them automatically when the duration runs out",
+			Example:     "tempban @user 7d spamming",
+			Confirm:     true,
+			Handler:     (*Bot).handleTempban,
+		},
+		{
+			Name:        "kick",
+			Category:    categoryModeration,
+			Tier:        tierMod,
+			Args:        []argSpec{{Name: "user", Kind: argUser, Description: "User to kick"}, reasonArg("Reason for the kick")},
+			Description: "Remove a user from the server (counts towards your quota)",
+			Slash:       true,
+			Confirm:     true,
+			Handler:     (*Bot).handleKick,
+		},
+		{
+			Name:     "mute",
+			Category: categoryModeration,
+			Tier:     tierMod,
+			Args: []argSpec{
+				{Name: "user", Kind: argUser, Description: "User to mute"},
+				{Name: "duration", Kind: argDuration, Optional: true, Description: "How long, e.g. 10m, 2h or 1d12h (omit for permanent)"},
+				reasonArg("Reason for the mute"),
+			},
+			Description: "Mute a user, permanently or for a duration like 10m, 2h or 1d12h",
+			Slash:       true,
+			Handler:     (*Bot).handleMute,
+		},
+		{
+			Name:        "unban",
+			Category:    categoryModeration,
+			Tier:        tierStaff,
+			Args:        []argSpec{{Name: "user", Kind: argUser, Description: "User (or user ID) to unban"}},
+			Description: "Remove a ban, by user ID or mention",
+			Example:     "unban 123456789012345678",
+			Slash:       true,
+			Handler:     (*Bot).handleUnban,
+		},
+		{
+			Name:        "unmute",
+			Category:    categoryModeration,
+			Tier:        tierMod,
+			Args:        []argSpec{{Name: "user", Kind: argUser, Description: "User to unmute"}},
+			Description: "Remove the mute from a user",
+			Slash:       true,
+			Handler:     (*Bot).handleUnmute,
+		},
+		{
+			Name:     "purge",
+			Aliases:  []string{"clear"},
+			Category: categoryModeration,
+			Tier:     tierMod,
+			Args: []argSpec{
+				{Name: "count", Kind: argInt, Description: fmt.Sprintf("How many messages to delete (max %d)", purgeMaxCount)},
+				{Name: "filters", Kind: argRest, Optional: true, Description: "Optional: " + purgeFilterHelp},
+			},
+			Description: "Bulk delete recent messages, optionally filtered (counts towards your quota)",
+			Example:     "purge 50 @user links",
+			Slash:       true,
+			Handler:     (*Bot).handlePurge,
+		},
+		{
+			Name:        "mod",
+			Category:    categoryRoles,
+			Tier:        tierStaff,
+			Args:        roleArgs("add", "remove"),
+			Description: "Add or remove the moderator role",
+			Slash:       true,
+			Handler:     (*Bot).handleMod,
+		},
+		{
+			Name:        "staffs",
+			Category:    categoryRoles,
+			Tier:        tierMod,
+			Args:        roleArgs("add", "remove"),
+			Description: "Add or remove the staff role",
+			Slash:       true,
+			Handler:     (*Bot).handleStaffs,
+		},
+		{
+			Name:        "vanity",
+			Category:    categoryRoles,
+			Tier:        tierStaff,
+			Args:        roleArgs("add", "remove", "check"),
+			Description: "Manually add, remove or check the vanity role",
+			Slash:       true,
+			Handler:     (*Bot).handleVanity,
+		},
+		{
+			Name:        "warn",
+			Category:    categoryRecords,
+			Tier:        tierMod,
+			Args:        []argSpec{{Name: "user", Kind: argUser, Description: "User to warn"}, reasonArg("Reason for the warning")},
+			Description: "Warn a user; reaching warning thresholds can mute, kick or ban automatically",
+			Handler:     (*Bot).handleWarn,
+		},
+		{
+			Name:        "warnings",
+			Category:    categoryRecords,
+			Tier:        tierEveryone,
+			Args:        []argSpec{{Name: "user", Kind: argUser, Optional: true, Description: "User to look up (mods only)"}},
+			Description: "List active warnings; anyone can view their own",
+			Handler:     (*Bot).handleWarnings,
+		},
+		{
+			Name:        "delwarn",
+			Category:    categoryRecords,
+			Tier:        tierStaff,
+			Args:        []argSpec{{Name: "number", Kind: argInt, Description: "Warning number", Invalid: "Invalid warning number."}},
+			Description: "Remove a warning",
+			Handler:     (*Bot).handleDelWarn,
+		},
+		{
+			Name:        "quota",
+			Category:    categoryRecords,
+			Tier:        tierMod,
+			Args:        []argSpec{{Name: "user", Kind: argUser, Optional: true, Description: "Moderator to look up (Admin/Staff only)"}},
+			Description: "Show how many bans, kicks and mutes you have left",
+			Handler:     (*Bot).handleQuota,
+		},
+		{
+			Name:        "case",
+			Category:    categoryRecords,
+			Tier:        tierMod,
+			Args:        []argSpec{{Name: "number", Kind: argInt, Description: "Case number", Invalid: "Invalid case number."}},
+			Description: "Show a moderation case",
+			Handler:     (*Bot).handleCase,
+		},
+		{
+			Name:     "cases",
+			Category: categoryRecords,
+			Tier:     tierMod,
+			Args: []argSpec{
+				{Name: "user", Kind: argUser, Description: "User to look up"},
+				{Name: "page", Kind: argInt, Optional: true, Description: "Page number", Invalid: "Invalid page number."},
+			},
+			Description: "List a user's moderation cases, newest first",
+			Handler:     (*Bot).handleCases,
+		},
+		{
+			Name:     "reason",
+			Category: categoryRecords,
+			Tier:     tierMod,
+			Args: []argSpec{
+				{Name: "number", Kind: argInt, Description: "Case number", Invalid: "Invalid case number."},
+				{Name: "reason", Kind: argRest, Description: "New reason"},
+			},
+			Description: "Change the reason of a case (mods can only amend their own)",
+			Handler:     (*Bot).handleReason,
+		},
+		{
+			Name:     "filter",
+			Category: categoryAutomod,
+			Tier:     tierStaff,
+			Args: []argSpec{
+				{Name: "action", Kind: argChoice, Choices: []string{"add", "remove", "list", "test"}},
+				{Name: "value", Kind: argRest, Optional: true, Description: "[word|wildcard|regex] pattern, rule number or text to test"},
+			},
+			Description: "Manage the blocked word list checked against messages, edits and nicknames",
+			Example:     "filter add wildcard fr*e n*tro",
+			Handler:     (*Bot).handleFilter,
+		},
+		{
+			Name:     "nick",
+			Aliases:  []string{"nickname"},
+			Category: categoryUser,
+			Tier:     tierEveryone,
+			Args: []argSpec{
+				{Name: "nickname", Kind: argRest, Description: "New nickname (1-32 characters, no @ or #)"},
+			},
+			Description: "Change your own nickname (needs Change Nickname or a staff role)",
+			Example:     "nick John Doe",
+			Slash:       true,
+			Handler:     (*Bot).handleNickname,
+		},
+		{
+			Name:        "help",
+			Aliases:     []string{"commands"},
+			Category:    categoryUser,
+			Tier:        tierEveryone,
+			Args:        []argSpec{{Name: "command", Kind: argWord, Optional: true, Description: "Command to show details for"}},
+			Description: "Show the available commands",
+			Slash:       true,
+			Handler:     (*Bot).handleHelp,
+		},
+	}
+}
+
+// roleArgs is the "<action> @user" spec of the role management commands
+func roleArgs(actions ...string) []argSpec {
+	return []argSpec{
+		{Name: "action", Kind: argChoice, Choices: actions},
+		{Name: "user", Kind: argUser, Description: "Target user"},
+	}
+}
+
 func (b *Bot) HandleCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
 	// Defensive check (should already be validated in onMessageCreate, but extra safety)
 	if m == nil || m.Message == nil || m.Author == nil {
 		return
 	}
 
+	ctx := newMessageContext(s, m)
+
 	content := strings.TrimPrefix(m.Content, config.Cfg.Prefix)
-	args := strings.Fields(content)
-	if len(args) == 0 {
+	words, err := splitArgs(content)
+	if err != nil {
+		ctx.Reply(err.Error())
+		return
+	}
+	if len(words) == 0 {
 		log.Printf("Command: No arguments found after prefix")
 		return
 	}
 
-	command := strings.ToLower(args[0])
-	log.Printf("Command: Processing command '%s' with args: %v", command, args[1:])
-
-	switch command {
-	case "ban":
-		b.handleBan(s, m, args[1:])
-	case "kick":
-		b.handleKick(s, m, args[1:])
-	case "mute":
-		b.handleMute(s, m, args[1:])
-	case "unban":
-		b.handleUnban(s, m, args[1:])
-	case "unmute":
-		b.handleUnmute(s, m, args[1:])
-	case "mod":
-		b.handleMod(s, m, args[1:])
-	case "staffs":
-		b.handleStaffs(s, m, args[1:])
-	case "vanity":
-		b.handleVanity(s, m, args[1:])
-	case "nick", "nickname":
-		b.handleNickname(s, m, args[1:])
-	case "help", "commands":
-		b.handleHelp(s, m)
-	default:
+	cmd := b.commands.lookup(words[0].Value)
+	if cmd == nil {
 		// Unknown command
 		return
 	}
-}
 
-func (b *Bot) handleBan(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
-	if len(args) < 1 {
-		s.ChannelMessageSend(m.ChannelID, "Usage: `"+config.Cfg.Prefix+"ban <@user> [reason]`")
-		return
+	rawArgs := ""
+	if len(words) > 1 {
+		rawArgs = words[1].Rest
 	}
+	log.Printf("Command: Processing command '%s' with args: %s", cmd.Name, rawArgs)
 
-	// Check permissions
-	hasAdmin, errAdmin := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleAdmin)
-	hasMod, errMod := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleMod)
-	hasStaff, errStaff := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleStaff)
-
-	log.Printf("Permission check for user %s (ID: %s) - Admin: %v (err: %v), Mod: %v (err: %v), Staff: %v (err: %v)",
-		m.Author.Username, m.Author.ID, hasAdmin, errAdmin, hasMod, errMod, hasStaff, errStaff)
-
-	if !hasAdmin && !hasMod && !hasStaff {
-		log.Printf("Permission denied for user %s attempting to ban", m.Author.Username)
-		s.ChannelMessageSend(m.ChannelID, "❌ You don't have permission to use this command.")
+	if !allowed(ctx, cmd) {
 		return
 	}
 
-	// Parse user ID
-	userID := parseUserID(args[0])
-	if userID == "" {
-		s.ChannelMessageSend(m.ChannelID, "❌ Invalid user mention.")
+	args, err := cmd.parseArgs(ctx, words[1:])
+	if err != nil {
+		ctx.Reply(err.Error())
 		return
 	}
 
-	// Check rate limiting for mods
-	if hasMod && !hasAdmin && !hasStaff {
-		canBan, err := utils.CanPerformModAction(m.Author.ID, "ban")
-		if err != nil {
-			s.ChannelMessageSend(m.ChannelID, "❌ Daily ban limit reached (10 bans per day).")
-			return
-		}
-		if !canBan {
-			s.ChannelMessageSend(m.ChannelID, "❌ Daily ban limit reached (10 bans per day).")
-			return
-		}
-		utils.RecordModAction(m.Author.ID, "ban")
+	b.execute(ctx, cmd, args)
+}
+
+func (b *Bot) handleBan(ctx *commandContext, args commandArgs) {
+	s := ctx.Session
+	userID := args.User("user")
+	reason := args.String("reason")
+
+	// Refuse targets at or above the moderator or the bot
+	if !checkHierarchy(ctx, userID) {
+		return
 	}
 
-	// Get reason
-	reason := "No reason provided"
-	if len(args) > 1 {
-		reason = strings.Join(args[1:], " ")
+	// Check moderation quota
+	quota, ok := checkQuota(ctx, utils.ActionBan)
+	if !ok {
+		return
 	}
 
 	// Ban user
-	err := s.GuildBanCreateWithReason(m.GuildID, userID, reason, 0)
+	c, err := b.banMember(s, ctx.GuildID, ctx.Author.ID, userID, reason, 0)
 	if err != nil {
+		quota.Cancel()
 		log.Printf("Error banning user: %v", err)
-		s.ChannelMessageSend(m.ChannelID, "❌ Failed to ban user.")
+		ctx.Reply("❌ Failed to ban user.")
 		return
 	}
 
-	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ User <@%s> has been banned. Reason: %s", userID, reason))
-
-	// Log to log channel
-	b.logAction(s, "🔨 **Ban**", m.Author.ID, userID, reason)
+	ctx.Reply(fmt.Sprintf("✅ User <@%s> has been banned. Reason: %s%s", userID, reason, caseSuffix(c)))
 }
 
-func (b *Bot) handleKick(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
-	if len(args) < 1 {
-		s.ChannelMessageSend(m.ChannelID, "Usage: `"+config.Cfg.Prefix+"kick <@user> [reason]`")
+func (b *Bot) handleTempban(ctx *commandContext, args commandArgs) {
+	s := ctx.Session
+	userID := args.User("user")
+	duration := args.Duration("duration")
+	reason := args.String("reason")
+
+	// Refuse targets at or above the moderator or the bot
+	if !checkHierarchy(ctx, userID) {
 		return
 	}
 
-	// Check permissions
-	hasAdmin, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleAdmin)
-	hasMod, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleMod)
-	hasStaff, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleStaff)
-
-	if !hasAdmin && !hasMod && !hasStaff {
-		s.ChannelMessageSend(m.ChannelID, "❌ You don't have permission to use this command.")
+	// Temporary bans count towards the ban quota
+	quota, ok := checkQuota(ctx, utils.ActionBan)
+	if !ok {
 		return
 	}
 
-	// Parse user ID
-	userID := parseUserID(args[0])
-	if userID == "" {
-		s.ChannelMessageSend(m.ChannelID, "❌ Invalid user mention.")
+	c, err := b.banMember(s, ctx.GuildID, ctx.Author.ID, userID, reason, duration)
+	if err != nil {
+		quota.Cancel()
+		log.Printf("Error temp-banning user: %v", err)
+		ctx.Reply("❌ Failed to ban user.")
 		return
 	}
 
-	// Check rate limiting for mods
-	if hasMod && !hasAdmin && !hasStaff {
-		canKick, err := utils.CanPerformModAction(m.Author.ID, "kick")
-		if err != nil {
-			s.ChannelMessageSend(m.ChannelID, "❌ Daily kick limit reached (10 kicks per day).")
-			return
-		}
-		if !canKick {
-			s.ChannelMessageSend(m.ChannelID, "❌ Daily kick limit reached (10 kicks per day).")
-			return
-		}
-		utils.RecordModAction(m.Author.ID, "kick")
+	ctx.Reply(fmt.Sprintf("✅ User <@%s> has been banned for %s. Reason: %s%s", userID, formatDuration(duration), reason, caseSuffix(c)))
+}
+
+func (b *Bot) handleKick(ctx *commandContext, args commandArgs) {
+	s := ctx.Session
+	userID := args.User("user")
+	reason := args.String("reason")
+
+	// Refuse targets at or above the moderator or the bot
+	if !checkHierarchy(ctx, userID) {
+		return
 	}
 
-	// Get reason
-	reason := "No reason provided"
-	if len(args) > 1 {
-		reason = strings.Join(args[1:], " ")
+	// Check moderation quota
+	quota, ok := checkQuota(ctx, utils.ActionKick)
+	if !ok {
+		return
 	}
 
 	// Kick user
-	err := s.GuildMemberDeleteWithReason(m.GuildID, userID, reason)
+	c, err := b.kickMember(s, ctx.GuildID, ctx.Author.ID, userID, reason)
 	if err != nil {
+		quota.Cancel()
 		log.Printf("Error kicking user: %v", err)
-		s.ChannelMessageSend(m.ChannelID, "❌ Failed to kick user.")
+		ctx.Reply("❌ Failed to kick user.")
 		return
 	}
 
-	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ User <@%s> has been kicked. Reason: %s", userID, reason))
-
-	// Log to log channel
-	b.logAction(s, "👢 **Kick**", m.Author.ID, userID, reason)
+	ctx.Reply(fmt.Sprintf("✅ User <@%s> has been kicked. Reason: %s%s", userID, reason, caseSuffix(c)))
 }
 
-func (b *Bot) handleMute(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
-	if len(args) < 1 {
-		s.ChannelMessageSend(m.ChannelID, "Usage: `"+config.Cfg.Prefix+"mute <@user> [duration] [reason]`")
-		return
-	}
+func (b *Bot) handleMute(ctx *commandContext, args commandArgs) {
+	s := ctx.Session
+	userID := args.User("user")
+	duration := args.Duration("duration") // 0 for a permanent mute
+	reason := args.String("reason")
 
-	// Check permissions
-	hasAdmin, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleAdmin)
-	hasMod, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleMod)
-	hasStaff, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleStaff)
-
-	if !hasAdmin && !hasMod && !hasStaff {
-		s.ChannelMessageSend(m.ChannelID, "❌ You don't have permission to use this command.")
+	// Refuse targets at or above the moderator or the bot
+	if !checkHierarchy(ctx, userID) {
 		return
 	}
 
-	// Parse user ID
-	userID := parseUserID(args[0])
-	if userID == "" {
-		s.ChannelMessageSend(m.ChannelID, "❌ Invalid user mention.")
+	timeout := useTimeout(duration)
+	if !timeout && config.Cfg.MuteRoleID == "" {
+		if config.Cfg.MuteMode == muteModeTimeout {
+			ctx.Reply("❌ Mute role not configured. Timeouts can't be permanent or longer than 28 days.")
+		} else {
+			ctx.Reply("❌ Mute role not configured.")
+		}
 		return
 	}
 
-	if config.Cfg.MuteRoleID == "" {
-		s.ChannelMessageSend(m.ChannelID, "❌ Mute role not configured.")
+	// Check moderation quota
+	quota, ok := checkQuota(ctx, utils.ActionMute)
+	if !ok {
 		return
 	}
 
-	// Add mute role
-	err := s.GuildMemberRoleAdd(m.GuildID, userID, config.Cfg.MuteRoleID)
+	// Time out or add mute role
+	c, err := b.muteMember(s, ctx.GuildID, ctx.Author.ID, userID, reason, duration)
 	if err != nil {
+		quota.Cancel()
 		log.Printf("Error muting user: %v", err)
 		// Check for specific permission errors
-		if strings.Contains(err.Error(), "403") || strings.Contains(err.Error(), "Missing Access") {
-			s.ChannelMessageSend(m.ChannelID, "❌ Failed to mute user: Bot doesn't have permission to assign the mute role.\n\n**Fix:**\n1. Ensure the bot has **Manage Roles** permission\n2. The bot's role must be **higher** than the mute role in the role hierarchy\n3. The mute role must be below the bot's highest role")
+		if (strings.Contains(err.Error(), "403") || strings.Contains(err.Error(), "Missing Access")) && timeout {
+			ctx.Reply("❌ Failed to mute user: Bot doesn't have permission to time out this user.\n\n**Fix:**\n1. Ensure the bot has **Timeout Members** permission\n2. The bot's role must be **higher** than the user's highest role\n3. Administrators can't be timed out")
+		} else if strings.Contains(err.Error(), "403") || strings.Contains(err.Error(), "Missing Access") {
+			ctx.Reply("❌ Failed to mute user: Bot doesn't have permission to assign the mute role.\n\n**Fix:**\n1. Ensure the bot has **Manage Roles** permission\n2. The bot's role must be **higher** than the mute role in the role hierarchy\n3. The mute role must be below the bot's highest role")
 		} else {
-			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Failed to mute user: %v", err))
+			ctx.Reply(fmt.Sprintf("❌ Failed to mute user: %v", err))
 		}
 		return
 	}
 
-	reason := "No reason provided"
-	if len(args) > 1 {
-		reason = strings.Join(args[1:], " ")
-	}
-
-	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ User <@%s> has been muted. Reason: %s", userID, reason))
-
-	// Log to log channel
-	b.logAction(s, "🔇 **Mute**", m.Author.ID, userID, reason)
-}
-
-func (b *Bot) handleUnban(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
-	if len(args) < 1 {
-		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Usage: `%sunban <user_id>` or `%sunban @user`\n\n**Note:** You can use either the user ID or mention the user.", config.Cfg.Prefix, config.Cfg.Prefix))
-		return
-	}
-
-	// Check permissions
-	hasAdmin, errAdmin := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleAdmin)
-	hasStaff, errStaff := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleStaff)
-
-	log.Printf("Unban permission check for user %s (ID: %s) - Admin: %v (err: %v), Staff: %v (err: %v)",
-		m.Author.Username, m.Author.ID, hasAdmin, errAdmin, hasStaff, errStaff)
-
-	if !hasAdmin && !hasStaff {
-		log.Printf("Permission denied for user %s attempting to unban", m.Author.Username)
-		s.ChannelMessageSend(m.ChannelID, "❌ You don't have permission to use this command. (Admin/Staff only)")
+	if duration > 0 {
+		ctx.Reply(fmt.Sprintf("✅ User <@%s> has been muted for %s. Reason: %s%s", userID, formatDuration(duration), reason, caseSuffix(c)))
 		return
 	}
 
-	// Parse user ID - support both mention and raw ID
-	userID := parseUserID(args[0])
-	if userID == "" {
-		// If not a mention, try as raw ID (must be numeric and 17-19 digits)
-		rawID := strings.TrimSpace(args[0])
-		if len(rawID) >= 17 && len(rawID) <= 19 {
-			// Check if it's numeric
-			isNumeric := true
-			for _, r := range rawID {
-				if r < '0' || r > '9' {
-					isNumeric = false
-					break
-				}
-			}
-			if isNumeric {
-				userID = rawID
-			}
-		}
-	}
+	ctx.Reply(fmt.Sprintf("✅ User <@%s> has been muted. Reason: %s%s", userID, reason, caseSuffix(c)))
+}
 
-	if userID == "" {
-		s.ChannelMessageSend(m.ChannelID, "❌ Invalid user ID or mention. Please provide a valid user ID or mention.\n\n**Example:** `"+config.Cfg.Prefix+"unban 123456789012345678` or `"+config.Cfg.Prefix+"unban @user`")
-		return
-	}
+func (b *Bot) handleUnban(ctx *commandContext, args commandArgs) {
+	s := ctx.Session
+	userID := args.User("user")
 
 	log.Printf("Unban: Attempting to unban user ID %s", userID)
 
 	// Unban user
-	err := s.GuildBanDelete(m.GuildID, userID)
+	err := s.GuildBanDelete(ctx.GuildID, userID)
 	if err != nil {
 		log.Printf("Error unbanning user %s: %v", userID, err)
 
 		// Provide helpful error messages
 		if strings.Contains(err.Error(), "404") || strings.Contains(err.Error(), "Unknown Ban") {
-			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ User <@%s> is not banned or doesn't exist.", userID))
+			ctx.Reply(fmt.Sprintf("❌ User <@%s> is not banned or doesn't exist.", userID))
 		} else if strings.Contains(err.Error(), "403") || strings.Contains(err.Error(), "Missing Access") {
-			s.ChannelMessageSend(m.ChannelID, "❌ Bot doesn't have permission to unban users.\n\n**Fix:**\n1. Ensure the bot has **Ban Members** permission\n2. Check that the bot role has proper permissions")
+			ctx.Reply("❌ Bot doesn't have permission to unban users.\n\n**Fix:**\n1. Ensure the bot has **Ban Members** permission\n2. Check that the bot role has proper permissions")
 		} else {
-			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Failed to unban user: %v", err))
+			ctx.Reply(fmt.Sprintf("❌ Failed to unban user: %v", err))
 		}
 		return
 	}
 
 	log.Printf("Unban: Successfully unbanned user %s", userID)
-	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ User <@%s> has been unbanned.", userID))
-
-	// Log to log channel
-	b.logAction(s, "✅ **Unban**", m.Author.ID, userID, "")
-}
 
-func (b *Bot) handleUnmute(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
-	if len(args) < 1 {
-		s.ChannelMessageSend(m.ChannelID, "Usage: `"+config.Cfg.Prefix+"unmute <@user>`")
-		return
-	}
+	// Manual unban makes any pending temporary ban expiry pointless
+	b.cancelExpiry(expiryActionUnban, ctx.GuildID, userID)
+	c := b.recordCase(ctx.GuildID, store.ActionUnban, ctx.Author.ID, userID, "", time.Time{})
 
-	// Check permissions
-	hasAdmin, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleAdmin)
-	hasMod, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleMod)
-	hasStaff, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleStaff)
+	ctx.Reply(fmt.Sprintf("✅ User <@%s> has been unbanned.%s", userID, caseSuffix(c)))
 
-	if !hasAdmin && !hasMod && !hasStaff {
-		s.ChannelMessageSend(m.ChannelID, "❌ You don't have permission to use this command.")
-		return
-	}
-
-	// Parse user ID
-	userID := parseUserID(args[0])
-	if userID == "" {
-		s.ChannelMessageSend(m.ChannelID, "❌ Invalid user mention.")
-		return
-	}
+	// Log to log channel
+	b.logCase(s, c)
+}
 
-	if config.Cfg.MuteRoleID == "" {
-		s.ChannelMessageSend(m.ChannelID, "❌ Mute role not configured.")
-		return
-	}
+func (b *Bot) handleUnmute(ctx *commandContext, args commandArgs) {
+	s := ctx.Session
+	userID := args.User("user")
 
-	// Remove mute role
-	err := s.GuildMemberRoleRemove(m.GuildID, userID, config.Cfg.MuteRoleID)
+	// Lift the timeout and/or remove the mute role
+	unmuted, err := b.unmuteMember(s, ctx.GuildID, userID)
 	if err != nil {
 		log.Printf("Error unmuting user: %v", err)
 		// Check for specific permission errors
 		if strings.Contains(err.Error(), "403") || strings.Contains(err.Error(), "Missing Access") {
-			s.ChannelMessageSend(m.ChannelID, "❌ Failed to unmute user: Bot doesn't have permission to remove the mute role.\n\n**Fix:**\n1. Ensure the bot has **Manage Roles** permission\n2. The bot's role must be **higher** than the mute role in the role hierarchy")
+			ctx.Reply("❌ Failed to unmute user: Bot doesn't have permission to remove the timeout or mute role.\n\n**Fix:**\n1. Ensure the bot has **Manage Roles** and **Timeout Members** permissions\n2. The bot's role must be **higher** than the mute role and the user's highest role")
+		} else if strings.Contains(err.Error(), "10007") || strings.Contains(err.Error(), "Unknown Member") {
+			ctx.Reply(fmt.Sprintf("❌ User <@%s> is not in the server.", userID))
 		} else {
-			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Failed to unmute user: %v", err))
+			ctx.Reply(fmt.Sprintf("❌ Failed to unmute user: %v", err))
 		}
 		return
 	}
 
-	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ User <@%s> has been unmuted.", userID))
-
-	// Log to log channel
-	b.logAction(s, "🔊 **Unmute**", m.Author.ID, userID, "")
-}
-
-func (b *Bot) handleMod(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
-	if len(args) < 2 {
-		s.ChannelMessageSend(m.ChannelID, "Usage: `"+config.Cfg.Prefix+"mod add <@user>` or `"+config.Cfg.Prefix+"mod remove <@user>`")
+	if !unmuted {
+		ctx.Reply(fmt.Sprintf("❌ User <@%s> is not muted.", userID))
 		return
 	}
 
-	// Check permissions - only admin can manage mod roles
-	hasAdmin, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleAdmin)
-	hasStaff, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleStaff)
+	c := b.recordCase(ctx.GuildID, store.ActionUnmute, ctx.Author.ID, userID, "", time.Time{})
 
-	if !hasAdmin && !hasStaff {
-		s.ChannelMessageSend(m.ChannelID, "❌ You don't have permission to use this command.")
-		return
-	}
+	ctx.Reply(fmt.Sprintf("✅ User <@%s> has been unmuted.%s", userID, caseSuffix(c)))
+
+	// Log to log channel
+	b.logCase(s, c)
+}
+
+func (b *Bot) handleMod(ctx *commandContext, args commandArgs) {
+	s := ctx.Session
+	action := args.String("action")
+	userID := args.User("user")
 
 	if config.Cfg.ModRoleID == "" {
-		s.ChannelMessageSend(m.ChannelID, "❌ Mod role not configured.")
+		ctx.Reply("❌ Mod role not configured.")
 		return
 	}
 
-	action := strings.ToLower(args[0])
-	userID := parseUserID(args[1])
-
-	if userID == "" {
-		s.ChannelMessageSend(m.ChannelID, "❌ Invalid user mention.")
+	// Refuse targets at or above the moderator or the bot
+	if !checkHierarchy(ctx, userID) {
 		return
 	}
 
@@ -375,63 +514,47 @@ func (b *Bot) handleMod(s *discordgo.Session, m *discordgo.MessageCreate, args [
 
 	switch action {
 	case "add":
-		err = s.GuildMemberRoleAdd(m.GuildID, userID, config.Cfg.ModRoleID)
+		err = s.GuildMemberRoleAdd(ctx.GuildID, userID, config.Cfg.ModRoleID)
 		if err == nil {
 			response = fmt.Sprintf("✅ Added <@%s> to mod role. Params: @mod <@%s>", userID, userID)
 		}
 	case "remove":
-		err = s.GuildMemberRoleRemove(m.GuildID, userID, config.Cfg.ModRoleID)
+		err = s.GuildMemberRoleRemove(ctx.GuildID, userID, config.Cfg.ModRoleID)
 		if err == nil {
 			response = fmt.Sprintf("✅ Removed <@%s> from mod role.", userID)
+			b.trackDestructiveAction(s, ctx.GuildID, ctx.Author.ID, nukeActionRoleRemove)
 		}
-	default:
-		s.ChannelMessageSend(m.ChannelID, "Usage: `"+config.Cfg.Prefix+"mod add <@user>` or `"+config.Cfg.Prefix+"mod remove <@user>`")
-		return
 	}
 
 	if err != nil {
 		log.Printf("Error managing mod role: %v", err)
-		s.ChannelMessageSend(m.ChannelID, "❌ Failed to manage mod role.")
+		ctx.Reply("❌ Failed to manage mod role.")
 		return
 	}
 
-	s.ChannelMessageSend(m.ChannelID, response)
+	ctx.Reply(response)
 
 	// Log to log channel
 	switch action {
 	case "add":
-		b.logAction(s, "👤 **Mod Role Added**", m.Author.ID, userID, "")
+		b.logAction(s, logModRoleAdded, ctx.Author.ID, userID, "")
 	case "remove":
-		b.logAction(s, "👤 **Mod Role Removed**", m.Author.ID, userID, "")
+		b.logAction(s, logModRoleRemoved, ctx.Author.ID, userID, "")
 	}
 }
 
-func (b *Bot) handleStaffs(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
-	if len(args) < 2 {
-		s.ChannelMessageSend(m.ChannelID, "Usage: `"+config.Cfg.Prefix+"staffs add <@user>` or `"+config.Cfg.Prefix+"staffs remove <@user>`")
-		return
-	}
-
-	// Check permissions - admin, staff, and mod can manage staff roles
-	hasAdmin, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleAdmin)
-	hasMod, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleMod)
-	hasStaff, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleStaff)
-
-	if !hasAdmin && !hasMod && !hasStaff {
-		s.ChannelMessageSend(m.ChannelID, "❌ You don't have permission to use this command.")
-		return
-	}
+func (b *Bot) handleStaffs(ctx *commandContext, args commandArgs) {
+	s := ctx.Session
+	action := args.String("action")
+	userID := args.User("user")
 
 	if config.Cfg.StaffRoleID == "" {
-		s.ChannelMessageSend(m.ChannelID, "❌ Staff role not configured.")
+		ctx.Reply("❌ Staff role not configured.")
 		return
 	}
 
-	action := strings.ToLower(args[0])
-	userID := parseUserID(args[1])
-
-	if userID == "" {
-		s.ChannelMessageSend(m.ChannelID, "❌ Invalid user mention.")
+	// Refuse targets at or above the moderator or the bot
+	if !checkHierarchy(ctx, userID) {
 		return
 	}
 
@@ -440,142 +563,47 @@ func (b *Bot) handleStaffs(s *discordgo.Session, m *discordgo.MessageCreate, arg
 
 	switch action {
 	case "add":
-		err = s.GuildMemberRoleAdd(m.GuildID, userID, config.Cfg.StaffRoleID)
+		err = s.GuildMemberRoleAdd(ctx.GuildID, userID, config.Cfg.StaffRoleID)
 		if err == nil {
 			response = fmt.Sprintf("✅ Added <@%s> to staff role.", userID)
 		}
 	case "remove":
-		err = s.GuildMemberRoleRemove(m.GuildID, userID, config.Cfg.StaffRoleID)
+		err = s.GuildMemberRoleRemove(ctx.GuildID, userID, config.Cfg.StaffRoleID)
 		if err == nil {
 			response = fmt.Sprintf("✅ Removed <@%s> from staff role.", userID)
+			b.trackDestructiveAction(s, ctx.GuildID, ctx.Author.ID, nukeActionRoleRemove)
 		}
-	default:
-		s.ChannelMessageSend(m.ChannelID, "Usage: `"+config.Cfg.Prefix+"staffs add <@user>` or `"+config.Cfg.Prefix+"staffs remove <@user>`")
-		return
 	}
 
 	if err != nil {
 		log.Printf("Error managing staff role: %v", err)
-		s.ChannelMessageSend(m.ChannelID, "❌ Failed to manage staff role.")
+		ctx.Reply("❌ Failed to manage staff role.")
 		return
 	}
 
-	s.ChannelMessageSend(m.ChannelID, response)
+	ctx.Reply(response)
 
 	// Log to log channel
 	switch action {
 	case "add":
-		b.logAction(s, "👥 **Staff Role Added**", m.Author.ID, userID, "")
+		b.logAction(s, logStaffRoleAdded, ctx.Author.ID, userID, "")
 	case "remove":
-		b.logAction(s, "👥 **Staff Role Removed**", m.Author.ID, userID, "")
+		b.logAction(s, logStaffRoleRemoved, ctx.Author.ID, userID, "")
 	}
 }
 
-func (b *Bot) handleVanity(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
-	if len(args) < 1 {
-		s.ChannelMessageSend(m.ChannelID, "Usage: `"+config.Cfg.Prefix+"vanity add <@user>` or `"+config.Cfg.Prefix+"vanity remove <@user>` or `"+config.Cfg.Prefix+"vanity check <@user>`")
-		return
-	}
-
-	action := strings.ToLower(args[0])
+func (b *Bot) handleVanity(ctx *commandContext, args commandArgs) {
+	s := ctx.Session
+	action := args.String("action")
+	userID := args.User("user")
 
-	// Handle check command separately
 	if action == "check" {
-		if len(args) < 2 {
-			s.ChannelMessageSend(m.ChannelID, "Usage: `"+config.Cfg.Prefix+"vanity check <@user>`")
-			return
-		}
-
-		// Check permissions
-		hasAdmin, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleAdmin)
-		hasStaff, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleStaff)
-
-		if !hasAdmin && !hasStaff {
-			s.ChannelMessageSend(m.ChannelID, "❌ You don't have permission to use this command.")
-			return
-		}
-
-		userID := parseUserID(args[1])
-		if userID == "" {
-			s.ChannelMessageSend(m.ChannelID, "❌ Invalid user mention.")
-			return
-		}
-
-		// Get member
-		member, err := s.GuildMember(m.GuildID, userID)
-		if err != nil {
-			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Error getting member: %v", err))
-			return
-		}
-
-		if member == nil {
-			s.ChannelMessageSend(m.ChannelID, "❌ Member not found.")
-			return
-		}
-
-		// Get presence
-		presence, err := s.State.Presence(m.GuildID, userID)
-		if err != nil {
-			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Error getting presence: %v", err))
-			return
-		}
-
-		// Check vanity status
-		var statusText string
-		if presence != nil {
-			statusText = b.getCustomStatusTextFromPresence(presence)
-		}
-
-		// Check if has role
-		hasRole := false
-		if config.Cfg.VanityRoleID != "" {
-			for _, roleID := range member.Roles {
-				if roleID == config.Cfg.VanityRoleID {
-					hasRole = true
-					break
-				}
-			}
-		}
-
-		hasVanity := false
-		if statusText != "" {
-			hasVanity = strings.Contains(strings.ToLower(statusText), strings.ToLower(config.Cfg.VanityString))
-		}
-
-		response := fmt.Sprintf("**Vanity Check for <@%s>:**\n", userID)
-		response += fmt.Sprintf("Status: `%s`\n", statusText)
-		response += fmt.Sprintf("Looking for: `%s`\n", config.Cfg.VanityString)
-		response += fmt.Sprintf("Has vanity string: `%v`\n", hasVanity)
-		response += fmt.Sprintf("Has role: `%v`\n", hasRole)
-		response += fmt.Sprintf("Should have role: `%v`", hasVanity && !hasRole)
-
-		s.ChannelMessageSend(m.ChannelID, response)
-		return
-	}
-
-	if len(args) < 2 {
-		s.ChannelMessageSend(m.ChannelID, "Usage: `"+config.Cfg.Prefix+"vanity add <@user>` or `"+config.Cfg.Prefix+"vanity remove <@user>` or `"+config.Cfg.Prefix+"vanity check <@user>`")
-		return
-	}
-
-	// Check permissions - admin and staff can manage vanity roles
-	hasAdmin, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleAdmin)
-	hasStaff, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleStaff)
-
-	if !hasAdmin && !hasStaff {
-		s.ChannelMessageSend(m.ChannelID, "❌ You don't have permission to use this command.")
+		b.checkVanity(ctx, userID)
 		return
 	}
 
 	if config.Cfg.VanityRoleID == "" {
-		s.ChannelMessageSend(m.ChannelID, "❌ Vanity role not configured.")
-		return
-	}
-
-	userID := parseUserID(args[1])
-
-	if userID == "" {
-		s.ChannelMessageSend(m.ChannelID, "❌ Invalid user mention.")
+		ctx.Reply("❌ Vanity role not configured.")
 		return
 	}
 
@@ -584,70 +612,106 @@ func (b *Bot) handleVanity(s *discordgo.Session, m *discordgo.MessageCreate, arg
 
 	switch action {
 	case "add":
-		err = s.GuildMemberRoleAdd(m.GuildID, userID, config.Cfg.VanityRoleID)
+		err = s.GuildMemberRoleAdd(ctx.GuildID, userID, config.Cfg.VanityRoleID)
 		if err == nil {
 			response = fmt.Sprintf("✅ Added <@%s> to vanity role.", userID)
 		}
 	case "remove":
-		err = s.GuildMemberRoleRemove(m.GuildID, userID, config.Cfg.VanityRoleID)
+		err = s.GuildMemberRoleRemove(ctx.GuildID, userID, config.Cfg.VanityRoleID)
 		if err == nil {
 			response = fmt.Sprintf("✅ Removed <@%s> from vanity role.", userID)
 		}
-	default:
-		s.ChannelMessageSend(m.ChannelID, "Usage: `"+config.Cfg.Prefix+"vanity add <@user>` or `"+config.Cfg.Prefix+"vanity remove <@user>`")
-		return
 	}
 
 	if err != nil {
 		log.Printf("Error managing vanity role: %v", err)
-		s.ChannelMessageSend(m.ChannelID, "❌ Failed to manage vanity role.")
+		ctx.Reply("❌ Failed to manage vanity role.")
 		return
 	}
 
-	s.ChannelMessageSend(m.ChannelID, response)
+	ctx.Reply(response)
 
 	// Log to log channel
 	switch action {
 	case "add":
-		b.logAction(s, "⭐ **Vanity Role Added**", m.Author.ID, userID, "")
+		b.logAction(s, logVanityRoleAdded, ctx.Author.ID, userID, "")
 	case "remove":
-		b.logAction(s, "⭐ **Vanity Role Removed**", m.Author.ID, userID, "")
+		b.logAction(s, logVanityRoleRemoved, ctx.Author.ID, userID, "")
 	}
 }
 
-// logAction sends a formatted log message to the log channel
-func (b *Bot) logAction(s *discordgo.Session, actionType string, moderatorID, targetID, reason string) {
-	if config.Cfg.LogChannelID == "" {
-		return // No log channel configured
+// checkVanity reports whether userID's status and role match the vanity rules
+func (b *Bot) checkVanity(ctx *commandContext, userID string) {
+	s := ctx.Session
+
+	// Get member
+	member, err := s.GuildMember(ctx.GuildID, userID)
+	if err != nil {
+		ctx.Reply(fmt.Sprintf("❌ Error getting member: %v", err))
+		return
 	}
 
-	// Get moderator info
-	moderator, err := s.User(moderatorID)
-	moderatorName := "Unknown"
-	if err == nil && moderator != nil {
-		moderatorName = moderator.Username
+	if member == nil {
+		ctx.Reply("❌ Member not found.")
+		return
 	}
 
-	// Get target info
-	target, err := s.User(targetID)
-	targetName := "Unknown"
-	if err == nil && target != nil {
-		targetName = target.Username
+	// Get presence
+	presence, err := s.State.Presence(ctx.GuildID, userID)
+	if err != nil {
+		ctx.Reply(fmt.Sprintf("❌ Error getting presence: %v", err))
+		return
 	}
 
-	// Build log message
-	logMsg := fmt.Sprintf("%s\n**Moderator:** <@%s> (%s)\n**Target:** <@%s> (%s)",
-		actionType, moderatorID, moderatorName, targetID, targetName)
+	// Check vanity status
+	var statusText string
+	if presence != nil {
+		statusText = b.getCustomStatusTextFromPresence(presence)
+	}
 
-	if reason != "" {
-		logMsg += fmt.Sprintf("\n**Reason:** %s", reason)
+	// Check if has role
+	hasRole := false
+	if config.Cfg.VanityRoleID != "" {
+		for _, roleID := range member.Roles {
+			if roleID == config.Cfg.VanityRoleID {
+				hasRole = true
+				break
+			}
+		}
 	}
 
-	// Send to log channel
-	_, err = s.ChannelMessageSend(config.Cfg.LogChannelID, logMsg)
-	if err != nil {
-		log.Printf("Error sending log message: %v", err)
+	hasVanity := false
+	if statusText != "" {
+		hasVanity = strings.Contains(strings.ToLower(statusText), strings.ToLower(config.Cfg.VanityString))
 	}
+
+	response := fmt.Sprintf("**Vanity Check for <@%s>:**\n", userID)
+	response += fmt.Sprintf("Status: `%s`\n", statusText)
+	response += fmt.Sprintf("Looking for: `%s`\n", config.Cfg.VanityString)
+	response += fmt.Sprintf("Has vanity string: `%v`\n", hasVanity)
+	response += fmt.Sprintf("Has role: `%v`\n", hasRole)
+	response += fmt.Sprintf("Should have role: `%v`", hasVanity && !hasRole)
+
+	ctx.Reply(response)
+}
+
+// checkHierarchy replies with the reason and returns false if the invoking
+// moderator may not act on targetID
+func checkHierarchy(ctx *commandContext, targetID string) bool {
+	err := utils.CheckHierarchy(ctx.Session, ctx.GuildID, ctx.Author.ID, targetID)
+	if err == nil {
+		return true
+	}
+
+	var hierarchyErr *utils.HierarchyError
+	if errors.As(err, &hierarchyErr) {
+		ctx.Reply(fmt.Sprintf("❌ Can't act on <@%s>: %s", targetID, hierarchyErr.Reason))
+		return false
+	}
+
+	log.Printf("Error checking role hierarchy for target %s: %v", targetID, err)
+	ctx.Reply("❌ Could not verify the role hierarchy, try again later.")
+	return false
 }
 
 // URL pattern to detect links (http, https, www., discord.gg, etc.)
@@ -682,35 +746,29 @@ func (b *Bot) handleAutoNickname(s *discordgo.Session, m *discordgo.MessageCreat
 	}
 
 	// Check if user wants to reset nickname to default
+	ctx := newMessageContext(s, m)
 	if strings.ToLower(newNickname) == "reset" {
-		b.resetNickname(s, m)
+		b.resetNickname(ctx)
 		return
 	}
 
 	// Validate and change nickname
-	b.changeNickname(s, m, newNickname)
+	b.changeNickname(ctx, newNickname)
 }
 
 // handleNickname handles nickname change requests via command
-func (b *Bot) handleNickname(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
-	if len(args) == 0 {
-		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Usage: `%snick <new nickname>`\nExample: `%snick John Doe`", config.Cfg.Prefix, config.Cfg.Prefix))
-		return
-	}
-
-	// Get the new nickname (join all args to allow spaces)
-	newNickname := strings.Join(args, " ")
-
+func (b *Bot) handleNickname(ctx *commandContext, args commandArgs) {
 	// Validate and change nickname
-	b.changeNickname(s, m, newNickname)
+	b.changeNickname(ctx, args.String("nickname"))
 }
 
 // changeNickname validates and changes the user's nickname
-func (b *Bot) changeNickname(s *discordgo.Session, m *discordgo.MessageCreate, newNickname string) {
+func (b *Bot) changeNickname(ctx *commandContext, newNickname string) {
+	s := ctx.Session
 
 	// Validate nickname length (Discord limit is 32 characters)
 	if len(newNickname) > 32 {
-		s.ChannelMessageSend(m.ChannelID, "❌ Nickname is too long! Maximum length is 32 characters.")
+		ctx.Reply("❌ Nickname is too long! Maximum length is 32 characters.")
 		return
 	}
 
@@ -720,39 +778,44 @@ func (b *Bot) changeNickname(s *discordgo.Session, m *discordgo.MessageCreate, n
 
 	// Check for potentially problematic characters
 	if strings.Contains(newNickname, "@") || strings.Contains(newNickname, "#") {
-		s.ChannelMessageSend(m.ChannelID, "❌ Nickname cannot contain @ or # symbols.")
+		ctx.Reply("❌ Nickname cannot contain @ or # symbols.")
+		return
+	}
+
+	if config.Cfg.AutomodEnabled && b.blocklist.match(newNickname) != nil {
+		ctx.Reply("❌ That nickname isn't allowed.")
 		return
 	}
 
 	// Check if this is the auto-nick channel - if so, skip permission checks
-	isAutoNickChannel := config.Cfg.AutoNickChannelID != "" && m.ChannelID == config.Cfg.AutoNickChannelID
+	isAutoNickChannel := config.Cfg.AutoNickChannelID != "" && ctx.ChannelID == config.Cfg.AutoNickChannelID
 
 	if !isAutoNickChannel {
 		// For command-based nickname changes, check permissions
 		// Get member to check current nickname
-		member, err := s.GuildMember(m.GuildID, m.Author.ID)
+		member, err := s.GuildMember(ctx.GuildID, ctx.Author.ID)
 		if err != nil {
 			log.Printf("Error getting member for nickname change: %v", err)
-			s.ChannelMessageSend(m.ChannelID, "❌ Error retrieving your member information.")
+			ctx.Reply("❌ Error retrieving your member information.")
 			return
 		}
 
 		// Check if user has permission to change nickname
 		// Users can change their own nickname if they have "Change Nickname" permission
 		// Or if they're admin/staff/mod, they can change it
-		hasAdmin, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleAdmin)
-		hasMod, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleMod)
-		hasStaff, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleStaff)
+		hasAdmin, _ := utils.HasPermission(s, ctx.GuildID, ctx.Author.ID, utils.RoleAdmin)
+		hasMod, _ := utils.HasPermission(s, ctx.GuildID, ctx.Author.ID, utils.RoleMod)
+		hasStaff, _ := utils.HasPermission(s, ctx.GuildID, ctx.Author.ID, utils.RoleStaff)
 
 		// Check if user has "Change Nickname" permission
 		canChangeNick := false
 		if member != nil {
 			// Check guild permissions - use guild-level permissions for nickname changes
-			guild, err := s.Guild(m.GuildID)
+			guild, err := s.Guild(ctx.GuildID)
 			if err == nil && guild != nil {
 				// Calculate member's guild permissions
 				var memberPerms int64
-				if guild.OwnerID == m.Author.ID {
+				if guild.OwnerID == ctx.Author.ID {
 					// Owner has all permissions
 					memberPerms = discordgo.PermissionAll
 					canChangeNick = true
@@ -772,90 +835,99 @@ func (b *Bot) changeNickname(s *discordgo.Session, m *discordgo.MessageCreate, n
 					}
 				}
 				log.Printf("Nickname: User %s - Admin: %v, Mod: %v, Staff: %v, CanChangeNick: %v, Perms: %d",
-					m.Author.Username, hasAdmin, hasMod, hasStaff, canChangeNick, memberPerms)
+					ctx.Author.Username, hasAdmin, hasMod, hasStaff, canChangeNick, memberPerms)
 			}
 		}
 
 		// Allow if user has admin/mod/staff role OR has ChangeNickname permission
 		if !hasAdmin && !hasMod && !hasStaff && !canChangeNick {
-			s.ChannelMessageSend(m.ChannelID, "❌ You don't have permission to change your nickname.\n\n**Required:** Change Nickname permission or Admin/Mod/Staff role")
-			log.Printf("Nickname: Permission denied for user %s", m.Author.Username)
+			ctx.Reply("❌ You don't have permission to change your nickname.\n\n**Required:** Change Nickname permission or Admin/Mod/Staff role")
+			log.Printf("Nickname: Permission denied for user %s", ctx.Author.Username)
 			return
 		}
 	} else {
-		log.Printf("Nickname: Auto-nick channel detected, skipping permission check for user %s", m.Author.Username)
+		log.Printf("Nickname: Auto-nick channel detected, skipping permission check for user %s", ctx.Author.Username)
 	}
 
 	// Change the nickname
-	log.Printf("Nickname: Attempting to change nickname for user %s to '%s' in guild %s", m.Author.Username, newNickname, m.GuildID)
-	err := s.GuildMemberNickname(m.GuildID, m.Author.ID, newNickname)
+	log.Printf("Nickname: Attempting to change nickname for user %s to '%s' in guild %s", ctx.Author.Username, newNickname, ctx.GuildID)
+	err := s.GuildMemberNickname(ctx.GuildID, ctx.Author.ID, newNickname)
 	if err != nil {
-		log.Printf("Nickname: ERROR changing nickname for user %s: %v", m.Author.ID, err)
+		log.Printf("Nickname: ERROR changing nickname for user %s: %v", ctx.Author.ID, err)
 
 		// Provide helpful error messages
 		if strings.Contains(err.Error(), "403") || strings.Contains(err.Error(), "Missing Access") {
-			s.ChannelMessageSend(m.ChannelID, "❌ Bot doesn't have permission to change nicknames.\n\n**Fix:**\n1. Ensure the bot has **Manage Nicknames** permission\n2. The bot's role must be **higher** than the user's highest role in the role hierarchy\n3. Check that the bot's role is properly positioned above all member roles")
+			ctx.Reply("❌ Bot doesn't have permission to change nicknames.\n\n**Fix:**\n1. Ensure the bot has **Manage Nicknames** permission\n2. The bot's role must be **higher** than the user's highest role in the role hierarchy\n3. Check that the bot's role is properly positioned above all member roles")
 		} else if strings.Contains(err.Error(), "50035") {
-			s.ChannelMessageSend(m.ChannelID, "❌ Invalid nickname format. Please use only valid characters.")
+			ctx.Reply("❌ Invalid nickname format. Please use only valid characters.")
 		} else if strings.Contains(err.Error(), "404") {
-			s.ChannelMessageSend(m.ChannelID, "❌ User or guild not found. Please try again.")
+			ctx.Reply("❌ User or guild not found. Please try again.")
 		} else {
-			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Failed to change nickname: %v", err))
+			ctx.Reply(fmt.Sprintf("❌ Failed to change nickname: %v", err))
 		}
 		return
 	}
 
 	// Add reaction to indicate success (removed success message to avoid spam)
-	s.MessageReactionAdd(m.ChannelID, m.Message.ID, "✅")
-	log.Printf("Nickname: Successfully changed nickname for user %s to '%s'", m.Author.Username, newNickname)
+	ctx.Success(fmt.Sprintf("Nickname changed to **%s**.", newNickname))
+	log.Printf("Nickname: Successfully changed nickname for user %s to '%s'", ctx.Author.Username, newNickname)
 
 	// Log the action
-	b.logAction(s, "📝 **Nickname Changed**", m.Author.ID, m.Author.ID, fmt.Sprintf("New nickname: %s", newNickname))
+	b.logAction(s, logNicknameChanged, ctx.Author.ID, ctx.Author.ID, fmt.Sprintf("New nickname: %s", newNickname))
 }
 
 // resetNickname resets the user's nickname to their default username
-func (b *Bot) resetNickname(s *discordgo.Session, m *discordgo.MessageCreate) {
-	log.Printf("Nickname: Resetting nickname for user %s to default", m.Author.Username)
+func (b *Bot) resetNickname(ctx *commandContext) {
+	s := ctx.Session
+
+	log.Printf("Nickname: Resetting nickname for user %s to default", ctx.Author.Username)
 
 	// Set nickname to empty string to reset to default (username)
-	err := s.GuildMemberNickname(m.GuildID, m.Author.ID, "")
+	err := s.GuildMemberNickname(ctx.GuildID, ctx.Author.ID, "")
 	if err != nil {
-		log.Printf("Nickname: ERROR resetting nickname for user %s: %v", m.Author.ID, err)
+		log.Printf("Nickname: ERROR resetting nickname for user %s: %v", ctx.Author.ID, err)
 
 		// Provide helpful error messages
 		if strings.Contains(err.Error(), "403") || strings.Contains(err.Error(), "Missing Access") {
-			s.ChannelMessageSend(m.ChannelID, "❌ Bot doesn't have permission to change nicknames.\n\n**Fix:**\n1. Ensure the bot has **Manage Nicknames** permission\n2. The bot's role must be **higher** than the user's highest role in the role hierarchy")
+			ctx.Reply("❌ Bot doesn't have permission to change nicknames.\n\n**Fix:**\n1. Ensure the bot has **Manage Nicknames** permission\n2. The bot's role must be **higher** than the user's highest role in the role hierarchy")
 		} else if strings.Contains(err.Error(), "404") {
-			s.ChannelMessageSend(m.ChannelID, "❌ User or guild not found. Please try again.")
+			ctx.Reply("❌ User or guild not found. Please try again.")
 		} else {
-			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Failed to reset nickname: %v", err))
+			ctx.Reply(fmt.Sprintf("❌ Failed to reset nickname: %v", err))
 		}
 		return
 	}
 
 	// Add reaction to indicate success
-	s.MessageReactionAdd(m.ChannelID, m.Message.ID, "✅")
-	log.Printf("Nickname: Successfully reset nickname for user %s to default", m.Author.Username)
+	ctx.Success("Nickname reset to your username.")
+	log.Printf("Nickname: Successfully reset nickname for user %s to default", ctx.Author.Username)
 
 	// Log the action
-	b.logAction(s, "📝 **Nickname Reset**", m.Author.ID, m.Author.ID, "Reset to default username")
+	b.logAction(s, logNicknameReset, ctx.Author.ID, ctx.Author.ID, "Reset to default username")
 }
 
-// handleHelp displays a comprehensive help menu with all available commands
-func (b *Bot) handleHelp(s *discordgo.Session, m *discordgo.MessageCreate) {
+// handleHelp lists the commands the user may run, generated from the registry
+func (b *Bot) handleHelp(ctx *commandContext, args commandArgs) {
+	s := ctx.Session
 	prefix := config.Cfg.Prefix
 
+	if name := args.String("command"); name != "" {
+		b.commandHelp(ctx, strings.TrimPrefix(name, prefix))
+		return
+	}
+
 	// Check user permissions to show appropriate commands
-	hasAdmin, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleAdmin)
-	hasMod, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleMod)
-	hasStaff, _ := utils.HasPermission(s, m.GuildID, m.Author.ID, utils.RoleStaff)
+	hasAdmin, _ := utils.HasPermission(s, ctx.GuildID, ctx.Author.ID, utils.RoleAdmin)
+	hasMod, _ := utils.HasPermission(s, ctx.GuildID, ctx.Author.ID, utils.RoleMod)
+	hasStaff, _ := utils.HasPermission(s, ctx.GuildID, ctx.Author.ID, utils.RoleStaff)
 
 	permissionLevel := getPermissionLevel(hasAdmin, hasMod, hasStaff)
+	userTier := memberTier(s, ctx.GuildID, ctx.Author.ID)
 
 	// Create main embed
 	embed := &discordgo.MessageEmbed{
 		Title:       "🤖 Bot Commands Help",
-		Description: fmt.Sprintf("Prefix: `%s`\n\nUse `%shelp` to view this menu.", prefix, prefix),
+		Description: fmt.Sprintf("Prefix: `%s`\nShowing commands available for %s.\n\nUse `%shelp <command>` for details on a command.", prefix, permissionLevel, prefix),
 		Color:       0x5865F2, // Discord blurple
 		Fields:      []*discordgo.MessageEmbedField{},
 		Footer: &discordgo.MessageEmbedFooter{
@@ -864,77 +936,36 @@ func (b *Bot) handleHelp(s *discordgo.Session, m *discordgo.MessageCreate) {
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
 
-	// Moderation Commands Section
-	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-		Name:   "📋 Moderation Commands",
-		Value:  fmt.Sprintf("Available commands for %s", permissionLevel),
-		Inline: false,
-	})
-
-	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-		Name:   "🔨 Ban",
-		Value:  fmt.Sprintf("`%sban @user [reason]`\n**Permission:** Admin/Staff (unlimited) | Mod (10/day)\n**Description:** Permanently bans a user from the server", prefix),
-		Inline: false,
-	})
-
-	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-		Name:   "👢 Kick",
-		Value:  fmt.Sprintf("`%skick @user [reason]`\n**Permission:** Admin/Staff (unlimited) | Mod (10/day)\n**Description:** Removes a user from the server", prefix),
-		Inline: false,
-	})
-
-	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-		Name:   "🔇 Mute",
-		Value:  fmt.Sprintf("`%smute @user [duration] [reason]`\n**Permission:** Admin/Mod/Staff (unlimited)\n**Description:** Mutes a user (prevents sending messages)", prefix),
-		Inline: false,
-	})
-
-	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-		Name:   "✅ Unban",
-		Value:  fmt.Sprintf("`%sunban <user_id>` or `%sunban @user`\n**Permission:** Admin/Staff only\n**Description:** Removes a ban from a user", prefix, prefix),
-		Inline: false,
-	})
-
-	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-		Name:   "🔊 Unmute",
-		Value:  fmt.Sprintf("`%sunmute @user`\n**Permission:** Admin/Mod/Staff\n**Description:** Removes mute from a user", prefix),
-		Inline: false,
-	})
-
-	// Role Management Commands (Admin/Staff only)
-	if hasAdmin || hasStaff {
-		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-			Name:   "👤 Mod Role",
-			Value:  fmt.Sprintf("`%smod add @user`\n`%smod remove @user`\n**Permission:** Admin/Staff\n**Description:** Add or remove moderator role", prefix, prefix),
-			Inline: false,
-		})
-
-		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-			Name:   "👥 Staff Role",
-			Value:  fmt.Sprintf("`%sstaffs add @user`\n`%sstaffs remove @user`\n**Permission:** Admin/Staff\n**Description:** Add or remove staff role", prefix, prefix),
-			Inline: false,
-		})
+	var plain []string
+	for _, category := range helpCategories {
+		var lines []string
+		for _, c := range b.commands.list {
+			if c.Category != category || c.Tier > userTier {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("`%s`\n└ %s", c.usage(), c.Description))
+			plain = append(plain, fmt.Sprintf("`%s` - %s", c.usage(), c.Description))
+		}
 
-		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-			Name:   "⭐ Vanity Role",
-			Value:  fmt.Sprintf("`%svanity add @user`\n`%svanity remove @user`\n`%svanity check @user`\n**Permission:** Admin/Staff\n**Description:** Manually manage vanity roles", prefix, prefix, prefix),
-			Inline: false,
-		})
+		// Field values are capped at 1024 characters
+		name := category
+		value := ""
+		for _, line := range lines {
+			if len(value)+len(line)+1 > 1024 {
+				embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: name, Value: value})
+				name = category + " (cont.)"
+				value = ""
+			}
+			if value != "" {
+				value += "\n"
+			}
+			value += line
+		}
+		if value != "" {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: name, Value: value})
+		}
 	}
 
-	// User Commands Section
-	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-		Name:   "👤 User Commands",
-		Value:  "Commands available to all users",
-		Inline: false,
-	})
-
-	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-		Name:   "📝 Nickname",
-		Value:  fmt.Sprintf("`%snick <new nickname>`\n`%snickname <new nickname>`\n**Permission:** All users (requires 'Change Nickname' permission or Admin/Mod/Staff role)\n**Description:** Change your own nickname\n**Restrictions:** 1-32 characters, cannot contain @ or #", prefix, prefix),
-		Inline: false,
-	})
-
 	// Auto-Nickname Channel Info
 	if config.Cfg.AutoNickChannelID != "" {
 		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
@@ -954,14 +985,51 @@ func (b *Bot) handleHelp(s *discordgo.Session, m *discordgo.MessageCreate) {
 	}
 
 	// Send the embed
-	_, err := s.ChannelMessageSendEmbed(m.ChannelID, embed)
+	err := ctx.ReplyEmbed(embed)
 	if err != nil {
 		log.Printf("Error sending help embed: %v", err)
 		// Fallback to plain text
-		helpText := fmt.Sprintf("**Bot Commands Help**\n\nPrefix: `%s`\n\n**Moderation:**\n`%sban @user [reason]` - Ban a user\n`%skick @user [reason]` - Kick a user\n`%smute @user [reason]` - Mute a user\n`%sunban <user_id>` - Unban a user\n`%sunmute @user` - Unmute a user\n\n**User Commands:**\n`%snick <nickname>` - Change your nickname\n\nUse `%shelp` for more information.",
-			prefix, prefix, prefix, prefix, prefix, prefix, prefix, prefix)
-		s.ChannelMessageSend(m.ChannelID, helpText)
+		helpText := fmt.Sprintf("**Bot Commands Help**\n\nPrefix: `%s`\n\n%s", prefix, strings.Join(plain, "\n"))
+		if r := []rune(helpText); len(r) > 2000 {
+			helpText = string(r[:1997]) + "..."
+		}
+		ctx.Reply(helpText)
+	}
+}
+
+// commandHelp shows usage, aliases and permission of a single command
+func (b *Bot) commandHelp(ctx *commandContext, name string) {
+	c := b.commands.lookup(name)
+	if c == nil {
+		ctx.Reply(fmt.Sprintf("❌ Unknown command `%s`. Use `%shelp` to list commands.", name, config.Cfg.Prefix))
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       config.Cfg.Prefix + c.Name,
+		Description: c.Description,
+		Color:       0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Usage", Value: "`" + c.usage() + "`", Inline: false},
+			{Name: "Permission", Value: c.Tier.String(), Inline: true},
+		},
+	}
+
+	if len(c.Aliases) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Aliases",
+			Value:  config.Cfg.Prefix + strings.Join(c.Aliases, ", "+config.Cfg.Prefix),
+			Inline: true,
+		})
+	}
+	if c.Slash {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Slash Command", Value: "/" + c.Name, Inline: true})
 	}
+	if c.Example != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Example", Value: "`" + config.Cfg.Prefix + c.Example + "`", Inline: false})
+	}
+
+	ctx.ReplyEmbed(embed)
 }
 
 // getPermissionLevel returns a string describing the user's permission level
@@ -978,22 +1046,37 @@ func getPermissionLevel(hasAdmin, hasMod, hasStaff bool) string {
 	return "Regular Users"
 }
 
-// parseUserID extracts user ID from mention string
-func parseUserID(mention string) string {
-	mention = strings.TrimSpace(mention)
-
-	// Remove <@ and >
-	if strings.HasPrefix(mention, "<@") && strings.HasSuffix(mention, ">") {
-		mention = mention[2 : len(mention)-1]
-		// Remove ! if present (nickname mention)
-		mention = strings.TrimPrefix(mention, "!")
-		return mention
+// capitalize upper-cases the first letter, e.g. "ban" -> "Ban"
+func capitalize(s string) string {
+	if s == "" {
+		return s
 	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
 
-	// Try parsing as direct ID
-	if _, err := strconv.ParseUint(mention, 10, 64); err == nil {
-		return mention
-	}
+// formatDuration renders a duration as e.g. "1d 12h" or "10m"
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+	d -= minutes * time.Minute
+	seconds := d / time.Second
 
-	return ""
+	var parts []string
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if minutes > 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes))
+	}
+	if seconds > 0 || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%ds", seconds))
+	}
+	return strings.Join(parts, " ")
 }
@@ -0,0 +1,258 @@
+package bot
+
+import (
+	"crypto/rand"
+	"discord-mod-bot/internal/config"
+	"discord-mod-bot/internal/utils"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Component custom ID prefixes of the confirmation buttons
+const (
+	confirmButtonPrefix = "confirm:"
+	cancelButtonPrefix  = "cancel:"
+)
+
+// pendingConfirmation is a command waiting for its moderator to click Confirm
+type pendingConfirmation struct {
+	cmd    *command
+	ctx    *commandContext
+	args   commandArgs
+	prompt *discordgo.Message
+	embed  *discordgo.MessageEmbed
+	timer  *time.Timer
+}
+
+// confirmations holds pending confirmations by button token
+type confirmations struct {
+	mu      sync.Mutex
+	pending map[string]*pendingConfirmation
+}
+
+func newConfirmations() *confirmations {
+	return &confirmations{pending: make(map[string]*pendingConfirmation)}
+}
+
+func (c *confirmations) add(token string, p *pendingConfirmation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending[token] = p
+}
+
+func (c *confirmations) get(token string) *pendingConfirmation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pending[token]
+}
+
+// take removes and returns the confirmation, so a click and the timeout
+// can never both act on it
+func (c *confirmations) take(token string) *pendingConfirmation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p := c.pending[token]
+	delete(c.pending, token)
+	return p
+}
+
+func newConfirmationToken() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+// requestConfirmation shows what cmd is about to do and runs it once the
+// invoking moderator clicks Confirm
+func (b *Bot) requestConfirmation(ctx *commandContext, cmd *command, args commandArgs) {
+	targetID := args.User("user")
+
+	// Don't ask about something that will be refused anyway
+	if targetID != "" && !checkHierarchy(ctx, targetID) {
+		return
+	}
+
+	timeout := time.Duration(config.Cfg.ConfirmTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	token := newConfirmationToken()
+	embed := b.confirmationEmbed(ctx, cmd, args, timeout)
+
+	prompt, err := ctx.ReplyComplex(&discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{embed},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Confirm", Style: discordgo.DangerButton, CustomID: confirmButtonPrefix + token},
+				discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: cancelButtonPrefix + token},
+			}},
+		},
+	})
+	if err != nil {
+		log.Printf("Confirm: Error sending confirmation for %s: %v", cmd.Name, err)
+		ctx.Reply("❌ Failed to ask for confirmation.")
+		return
+	}
+
+	p := &pendingConfirmation{cmd: cmd, ctx: ctx, args: args, prompt: prompt, embed: embed}
+	p.timer = time.AfterFunc(timeout, func() {
+		if b.confirmations.take(token) == nil {
+			return // Already answered
+		}
+
+		embed.Color = 0x99AAB5 // Gray
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: "⌛ Timed out, nothing was done"}
+		if err := ctx.EditReply(prompt, []*discordgo.MessageEmbed{embed}, []discordgo.MessageComponent{}); err != nil {
+			log.Printf("Confirm: Error expiring confirmation for %s: %v", cmd.Name, err)
+		}
+	})
+	b.confirmations.add(token, p)
+}
+
+// confirmationEmbed describes the pending action and its target
+func (b *Bot) confirmationEmbed(ctx *commandContext, cmd *command, args commandArgs, timeout time.Duration) *discordgo.MessageEmbed {
+	s := ctx.Session
+	targetID := args.User("user")
+
+	description := fmt.Sprintf("**%s** <@%s>?", capitalize(cmd.Name), targetID)
+	if d := args.Duration("duration"); d > 0 {
+		description += fmt.Sprintf("\n**Duration:** %s", formatDuration(d))
+	}
+	if reason := args.String("reason"); reason != "" {
+		description += fmt.Sprintf("\n**Reason:** %s", reason)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "⚠️ Confirm " + capitalize(cmd.Name),
+		Description: description,
+		Color:       0xFEE75C, // Yellow
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Only %s can confirm • expires in %s", ctx.Author.Username, formatDuration(timeout)),
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	if user, err := s.User(targetID); err == nil && user != nil {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: user.AvatarURL("128")}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: "User", Value: fmt.Sprintf("%s (`%s`)", user.Username, user.ID), Inline: true,
+		})
+	}
+
+	if created, err := discordgo.SnowflakeTimestamp(targetID); err == nil {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: "Account Created", Value: fmt.Sprintf("<t:%d:R>", created.Unix()), Inline: true,
+		})
+	}
+
+	joined := "Not a member"
+	if member, err := utils.GetMember(s, ctx.GuildID, targetID); err == nil && member != nil && !member.JoinedAt.IsZero() {
+		joined = fmt.Sprintf("<t:%d:R>", member.JoinedAt.Unix())
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Joined", Value: joined, Inline: true})
+
+	history := "None"
+	if cases, err := b.store.CasesForUser(targetID); err != nil {
+		log.Printf("Confirm: Error loading cases for user %s: %v", targetID, err)
+		history = "Unavailable"
+	} else if len(cases) > 0 {
+		// Newest three, then a count of the rest
+		var lines []string
+		for i := len(cases) - 1; i >= 0 && len(lines) < 3; i-- {
+			c := cases[i]
+			lines = append(lines, fmt.Sprintf("`#%d` %s <t:%d:d>", c.ID, caseLabel(c.Action), c.CreatedAt.Unix()))
+		}
+		history = strings.Join(lines, "\n")
+		if len(cases) > 3 {
+			history += fmt.Sprintf("\n…and %d more (`%scases @user`)", len(cases)-3, config.Cfg.Prefix)
+		}
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name: "Prior Cases", Value: history, Inline: false,
+	})
+
+	return embed
+}
+
+// handleConfirmationButton answers a click on a Confirm or Cancel button
+func (b *Bot) handleConfirmationButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	customID := i.MessageComponentData().CustomID
+	confirmed := strings.HasPrefix(customID, confirmButtonPrefix)
+	token := strings.TrimPrefix(strings.TrimPrefix(customID, confirmButtonPrefix), cancelButtonPrefix)
+
+	clicker := i.User
+	if i.Member != nil && i.Member.User != nil {
+		clicker = i.Member.User
+	}
+	if clicker == nil {
+		return
+	}
+
+	p := b.confirmations.get(token)
+	if p == nil {
+		respondEphemeral(s, i, "⌛ This confirmation has expired.")
+		return
+	}
+
+	if clicker.ID != p.ctx.Author.ID {
+		respondEphemeral(s, i, fmt.Sprintf("❌ Only <@%s> can answer this confirmation.", p.ctx.Author.ID))
+		return
+	}
+
+	if b.confirmations.take(token) == nil {
+		respondEphemeral(s, i, "⌛ This confirmation has expired.")
+		return
+	}
+	p.timer.Stop()
+
+	embed := p.embed
+	if confirmed {
+		embed.Color = 0x57F287 // Green
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: "✅ Confirmed by " + clicker.Username}
+	} else {
+		embed.Color = 0x99AAB5 // Gray
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: "Cancelled by " + clicker.Username}
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		log.Printf("Confirm: Error updating confirmation for %s: %v", p.cmd.Name, err)
+	}
+
+	if !confirmed {
+		log.Printf("Confirm: %s of user %s cancelled by %s", p.cmd.Name, p.args.User("user"), clicker.ID)
+		return
+	}
+
+	log.Printf("Confirm: %s of user %s confirmed by %s", p.cmd.Name, p.args.User("user"), clicker.ID)
+	p.cmd.Handler(b, p.ctx, p.args)
+}
+
+// respondEphemeral answers an interaction with a message only the clicker sees
+func respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("Error answering interaction: %v", err)
+	}
+}
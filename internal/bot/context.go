@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"log"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// commandContext carries who invoked a command and how to answer, so the
+// same command logic serves prefix messages and slash commands
+type commandContext struct {
+	Session   *discordgo.Session
+	GuildID   string
+	ChannelID string
+	Author    *discordgo.User
+
+	// Exactly one of these is set
+	message     *discordgo.Message
+	interaction *discordgo.Interaction
+
+	mu        sync.Mutex
+	responded bool // interaction only: the deferred response was filled in
+}
+
+func newMessageContext(s *discordgo.Session, m *discordgo.MessageCreate) *commandContext {
+	return &commandContext{
+		Session:   s,
+		GuildID:   m.GuildID,
+		ChannelID: m.ChannelID,
+		Author:    m.Author,
+		message:   m.Message,
+	}
+}
+
+func newInteractionContext(s *discordgo.Session, i *discordgo.InteractionCreate) *commandContext {
+	author := i.User
+	if i.Member != nil && i.Member.User != nil {
+		author = i.Member.User
+	}
+
+	return &commandContext{
+		Session:     s,
+		GuildID:     i.GuildID,
+		ChannelID:   i.ChannelID,
+		Author:      author,
+		interaction: i.Interaction,
+	}
+}
+
+// isSlash reports whether the command came from an interaction
+func (c *commandContext) isSlash() bool {
+	return c.interaction != nil
+}
+
+// Reply sends a text answer to the invoking channel or interaction
+func (c *commandContext) Reply(content string) error {
+	_, err := c.send(&discordgo.MessageSend{Content: content})
+	return err
+}
+
+// ReplyEmbed sends an embed answer to the invoking channel or interaction
+func (c *commandContext) ReplyEmbed(embed *discordgo.MessageEmbed) error {
+	_, err := c.send(&discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}})
+	return err
+}
+
+// ReplyComplex sends an answer with components and returns the message so
+// it can be edited later with EditReply
+func (c *commandContext) ReplyComplex(msg *discordgo.MessageSend) (*discordgo.Message, error) {
+	return c.send(msg)
+}
+
+// Success acknowledges a command that needs no further text: a ✅ reaction
+// on prefix commands, a short reply on slash commands
+func (c *commandContext) Success(content string) {
+	if c.isSlash() {
+		if err := c.Reply("✅ " + content); err != nil {
+			log.Printf("Error answering interaction: %v", err)
+		}
+		return
+	}
+
+	if err := c.Session.MessageReactionAdd(c.ChannelID, c.message.ID, "✅"); err != nil {
+		log.Printf("Error adding reaction: %v", err)
+	}
+}
+
+func (c *commandContext) send(msg *discordgo.MessageSend) (*discordgo.Message, error) {
+	if !c.isSlash() {
+		return c.Session.ChannelMessageSendComplex(c.ChannelID, msg)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Slash commands are deferred on receipt; the first answer fills in the
+	// deferred response and later ones are sent as follow-ups
+	if !c.responded {
+		content := msg.Content
+		embeds := msg.Embeds
+		components := msg.Components
+		sent, err := c.Session.InteractionResponseEdit(c.interaction, &discordgo.WebhookEdit{
+			Content:    &content,
+			Embeds:     &embeds,
+			Components: &components,
+		})
+		c.responded = err == nil
+		return sent, err
+	}
+
+	return c.Session.FollowupMessageCreate(c.interaction, true, &discordgo.WebhookParams{
+		Content:    msg.Content,
+		Embeds:     msg.Embeds,
+		Components: msg.Components,
+	})
+}
+
+// EditReply replaces the embeds and components of a message sent through
+// this context
+func (c *commandContext) EditReply(msg *discordgo.Message, embeds []*discordgo.MessageEmbed, components []discordgo.MessageComponent) error {
+	if !c.isSlash() {
+		_, err := c.Session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:         msg.ID,
+			Channel:    msg.ChannelID,
+			Embeds:     embeds,
+			Components: components,
+		})
+		return err
+	}
+
+	// Interaction messages belong to the webhook, not the channel
+	_, err := c.Session.FollowupMessageEdit(c.interaction, msg.ID, &discordgo.WebhookEdit{
+		Embeds:     &embeds,
+		Components: &components,
+	})
+	return err
+}
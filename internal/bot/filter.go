@@ -0,0 +1,397 @@
+package bot
+
+import (
+	"discord-mod-bot/internal/config"
+	"discord-mod-bot/internal/store"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxFilterPattern is the longest pattern !filter add accepts
+const maxFilterPattern = 200
+
+// confusables maps look-alike characters to the letter they imitate:
+// leetspeak, accented Latin and Cyrillic/Greek homoglyphs
+var confusables = func() map[rune]rune {
+	groups := map[rune]string{
+		'a': "4@àáâãäåāăąǎαаɑ",
+		'b': "8ßвь",
+		'c': "(¢çćĉċčсϲ",
+		'd': "ďđԁ",
+		'e': "3€èéêëēĕėęěеєε",
+		'g': "9ĝğġģɡ",
+		'h': "ĥħнһ",
+		'i': "1!|¡ìíîïĩīĭįıіїιӏ",
+		'j': "ĵј",
+		'k': "ķκк",
+		'l': "ĺļľŀł",
+		'm': "м",
+		'n': "ñńņňŉηп",
+		'o': "0°òóôõöøōŏőοσоө",
+		'p': "ρр",
+		'r': "ŕŗřг",
+		's': "5$śŝşšѕ",
+		't': "7+ţťŧτт",
+		'u': "ùúûüũūŭůűųμυ",
+		'v': "ν",
+		'w': "ŵωш",
+		'x': "×хχ",
+		'y': "ýÿŷуγ",
+		'z': "2źżžζ",
+	}
+
+	m := make(map[rune]rune)
+	for letter, chars := range groups {
+		for _, r := range chars {
+			m[r] = letter
+		}
+	}
+	return m
+}()
+
+// normalizeText lowercases s and folds leetspeak, accents, full-width
+// letters and homoglyphs to plain ASCII letters, dropping invisible
+// characters, so "Fr33 Nítrо" and "free nitro" compare equal
+func normalizeText(s string) string {
+	return foldText(s, true)
+}
+
+// foldText is normalizeText; with symbols false, punctuation such as ! or $
+// is kept as is so "scam!" still ends in a word boundary
+func foldText(s string, symbols bool) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		// Zero-width spaces, soft hyphens and combining accents
+		if unicode.Is(unicode.Cf, r) || unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		// Full-width forms like ｆｒｅｅ
+		if r >= 0xFF01 && r <= 0xFF5E {
+			r -= 0xFEE0
+		}
+		r = unicode.ToLower(r)
+		if letter, ok := confusables[r]; ok && (symbols || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			r = letter
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Word boundaries for word and wildcard rules
+const (
+	wordStart = `(?:^|[^\p{L}\p{N}])`
+	wordEnd   = `(?:$|[^\p{L}\p{N}])`
+)
+
+// compileFilterRule turns a rule into the regexp matched against text.
+// Word and wildcard rules are matched against normalized text, regex rules
+// against both the original and the normalized text.
+func compileFilterRule(kind, pattern string) (*regexp.Regexp, error) {
+	switch kind {
+	case store.FilterWord:
+		words := strings.Fields(normalizeText(pattern))
+		if len(words) == 0 {
+			return nil, fmt.Errorf("empty pattern")
+		}
+		for i, w := range words {
+			words[i] = regexp.QuoteMeta(w)
+		}
+		return regexp.Compile(wordStart + strings.Join(words, `\s+`) + wordEnd)
+
+	case store.FilterWildcard:
+		// Fold the pattern without touching the wildcards
+		var expr strings.Builder
+		for _, r := range pattern {
+			switch {
+			case r == '*':
+				expr.WriteString(`[\p{L}\p{N}]*`)
+			case r == '?':
+				expr.WriteString(`[\p{L}\p{N}]`)
+			case unicode.IsSpace(r):
+				expr.WriteString(`\s+`)
+			default:
+				expr.WriteString(regexp.QuoteMeta(normalizeText(string(r))))
+			}
+		}
+		if strings.Trim(pattern, "*? ") == "" {
+			return nil, fmt.Errorf("the pattern must contain more than wildcards")
+		}
+		return regexp.Compile(wordStart + expr.String() + wordEnd)
+
+	case store.FilterRegex:
+		return regexp.Compile("(?i)" + pattern)
+	}
+	return nil, fmt.Errorf("unknown rule kind %q", kind)
+}
+
+// blocklist holds the compiled filter rules
+type blocklist struct {
+	mu    sync.RWMutex
+	rules []compiledFilter
+}
+
+type compiledFilter struct {
+	rule *store.FilterRule
+	re   *regexp.Regexp
+}
+
+// set replaces the rules. Rules that no longer compile are skipped.
+func (bl *blocklist) set(rules []*store.FilterRule) {
+	compiled := make([]compiledFilter, 0, len(rules))
+	for _, r := range rules {
+		re, err := compileFilterRule(r.Kind, r.Pattern)
+		if err != nil {
+			log.Printf("Filter: Skipping rule #%d: %v", r.ID, err)
+			continue
+		}
+		compiled = append(compiled, compiledFilter{rule: r, re: re})
+	}
+
+	bl.mu.Lock()
+	bl.rules = compiled
+	bl.mu.Unlock()
+}
+
+// matches returns the rules text breaks, stopping after the first if all is false
+func (bl *blocklist) matches(text string, all bool) []*store.FilterRule {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	// Leetspeak symbols are ambiguous, so text is checked with and without
+	normalized := normalizeText(text)
+	plain := foldText(text, false)
+	lower := strings.ToLower(text)
+
+	bl.mu.RLock()
+	defer bl.mu.RUnlock()
+
+	var hits []*store.FilterRule
+	for _, f := range bl.rules {
+		hit := f.re.MatchString(normalized) || f.re.MatchString(plain)
+		if !hit && f.rule.Kind == store.FilterRegex {
+			hit = f.re.MatchString(lower)
+		}
+		if !hit {
+			continue
+		}
+		hits = append(hits, f.rule)
+		if !all {
+			break
+		}
+	}
+	return hits
+}
+
+// match returns the first rule text breaks, or nil
+func (bl *blocklist) match(text string) *store.FilterRule {
+	if hits := bl.matches(text, false); len(hits) > 0 {
+		return hits[0]
+	}
+	return nil
+}
+
+// loadBlocklist compiles the rules saved in the store
+func (b *Bot) loadBlocklist() error {
+	rules, err := b.store.FilterRules()
+	if err != nil {
+		return err
+	}
+	b.blocklist.set(rules)
+	return nil
+}
+
+// describeFilterRule renders a rule for lists and logs
+func describeFilterRule(r *store.FilterRule) string {
+	return fmt.Sprintf("`#%d` %s `%s`", r.ID, r.Kind, strings.ReplaceAll(r.Pattern, "`", "'"))
+}
+
+// checkBlocklist is the automod check for blocked words
+func (b *Bot) checkBlocklist(s *discordgo.Session, m *discordgo.Message) *automodViolation {
+	rule := b.blocklist.match(m.Content)
+	if rule == nil {
+		return nil
+	}
+	return &automodViolation{
+		Rule:   "Blocklist",
+		Reason: fmt.Sprintf("Matched filter rule #%d", rule.ID),
+		Detail: describeFilterRule(rule),
+		Action: b.filterAction,
+	}
+}
+
+// filterNickname resets a new nickname that matches the blocklist. Updates
+// that keep beforeNick, e.g. after a failed reset, aren't checked again.
+func (b *Bot) filterNickname(s *discordgo.Session, m *discordgo.Member, beforeNick string) {
+	if !config.Cfg.AutomodEnabled || m.Nick == "" || m.Nick == beforeNick {
+		return
+	}
+
+	rule := b.blocklist.match(m.Nick)
+	if rule == nil || hasAnyModRole(s, m.GuildID, m.User.ID) {
+		return
+	}
+
+	log.Printf("AutoMod: Nickname of user %s matched filter rule #%d", m.User.ID, rule.ID)
+
+	result := "Nickname reset"
+	if err := s.GuildMemberNickname(m.GuildID, m.User.ID, ""); err != nil {
+		log.Printf("AutoMod: Error resetting nickname of %s: %v", m.User.ID, err)
+		result = fmt.Sprintf("⚠️ Nickname reset failed: %v", err)
+	}
+
+	v := &automodViolation{
+		Rule:   "Blocklist",
+		Reason: fmt.Sprintf("Nickname matched filter rule #%d", rule.ID),
+		Detail: describeFilterRule(rule),
+		Action: b.filterAction,
+	}
+	if v.Action.Kind != automodDelete {
+		result += "\n" + b.applyAutomodAction(s, m.GuildID, m.User.ID, v)
+	}
+
+	b.logAutomod(s, m.User, v, result,
+		&discordgo.MessageEmbedField{Name: "Nickname", Value: truncate(m.Nick, 1024), Inline: true},
+	)
+}
+
+func (b *Bot) handleFilter(ctx *commandContext, args commandArgs) {
+	switch args.String("action") {
+	case "add":
+		b.filterAdd(ctx, args.String("value"))
+	case "remove":
+		b.filterRemove(ctx, args.String("value"))
+	case "list":
+		b.filterList(ctx)
+	case "test":
+		b.filterTest(ctx, args.String("value"))
+	}
+}
+
+// filterAdd adds a rule: "[word|wildcard|regex] <pattern>", word if no kind is given
+func (b *Bot) filterAdd(ctx *commandContext, value string) {
+	kind := store.FilterWord
+	pattern := strings.TrimSpace(value)
+	if first, rest, ok := strings.Cut(pattern, " "); ok {
+		switch k := strings.ToLower(first); k {
+		case store.FilterWord, store.FilterWildcard, store.FilterRegex:
+			kind, pattern = k, strings.TrimSpace(rest)
+		}
+	}
+
+	if pattern == "" {
+		ctx.Reply(fmt.Sprintf("❌ Usage: `%sfilter add [word|wildcard|regex] <pattern>`", config.Cfg.Prefix))
+		return
+	}
+	if len([]rune(pattern)) > maxFilterPattern {
+		ctx.Reply(fmt.Sprintf("❌ Patterns can be at most %d characters.", maxFilterPattern))
+		return
+	}
+	if _, err := compileFilterRule(kind, pattern); err != nil {
+		ctx.Reply(fmt.Sprintf("❌ Invalid %s pattern: %v", kind, err))
+		return
+	}
+
+	rule := &store.FilterRule{Kind: kind, Pattern: pattern, CreatedBy: ctx.Author.ID}
+	if err := b.store.CreateFilterRule(rule); err != nil {
+		log.Printf("Filter: Error saving rule: %v", err)
+		ctx.Reply("❌ Failed to save the rule.")
+		return
+	}
+	if err := b.loadBlocklist(); err != nil {
+		log.Printf("Filter: Error reloading rules: %v", err)
+	}
+
+	log.Printf("Filter: %s added rule #%d (%s %q)", ctx.Author.ID, rule.ID, rule.Kind, rule.Pattern)
+	ctx.Reply(fmt.Sprintf("✅ Added filter rule %s.", describeFilterRule(rule)))
+}
+
+func (b *Bot) filterRemove(ctx *commandContext, value string) {
+	id, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(value), "#"))
+	if err != nil || id < 1 {
+		ctx.Reply(fmt.Sprintf("❌ Usage: `%sfilter remove <rule number>`", config.Cfg.Prefix))
+		return
+	}
+
+	rule, err := b.store.DeleteFilterRule(id)
+	if errors.Is(err, store.ErrNotFound) {
+		ctx.Reply(fmt.Sprintf("❌ Filter rule #%d not found.", id))
+		return
+	}
+	if err != nil {
+		log.Printf("Filter: Error deleting rule #%d: %v", id, err)
+		ctx.Reply("❌ Failed to remove the rule.")
+		return
+	}
+	if err := b.loadBlocklist(); err != nil {
+		log.Printf("Filter: Error reloading rules: %v", err)
+	}
+
+	log.Printf("Filter: %s removed rule #%d", ctx.Author.ID, rule.ID)
+	ctx.Reply(fmt.Sprintf("✅ Removed filter rule %s.", describeFilterRule(rule)))
+}
+
+func (b *Bot) filterList(ctx *commandContext) {
+	rules, err := b.store.FilterRules()
+	if err != nil {
+		log.Printf("Filter: Error loading rules: %v", err)
+		ctx.Reply("❌ Failed to load the filter rules.")
+		return
+	}
+
+	if len(rules) == 0 {
+		ctx.Reply(fmt.Sprintf("✅ The blocklist is empty. Add rules with `%sfilter add`.", config.Cfg.Prefix))
+		return
+	}
+
+	lines := make([]string, len(rules))
+	for i, r := range rules {
+		lines[i] = describeFilterRule(r)
+	}
+
+	// Embed descriptions are capped at 4096 characters
+	description := strings.Join(lines, "\n")
+	if r := []rune(description); len(r) > 4000 {
+		description = string(r[:4000]) + "\n…"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("🚫 Filter Rules (%d)", len(rules)),
+		Description: description,
+		Color:       colorOrange,
+	}
+	if !config.Cfg.AutomodEnabled {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: "AutoMod is disabled; set AUTOMOD_ENABLED=true to enforce these rules"}
+	}
+	ctx.ReplyEmbed(embed)
+}
+
+// filterTest shows which rules a text would break, without acting on it
+func (b *Bot) filterTest(ctx *commandContext, text string) {
+	if strings.TrimSpace(text) == "" {
+		ctx.Reply(fmt.Sprintf("❌ Usage: `%sfilter test <text>`", config.Cfg.Prefix))
+		return
+	}
+
+	hits := b.blocklist.matches(text, true)
+	normalized := fmt.Sprintf("Normalized: `%s`", truncate(strings.ReplaceAll(normalizeText(text), "`", "'"), 500))
+	if len(hits) == 0 {
+		ctx.Reply("✅ No filter rule matches.\n" + normalized)
+		return
+	}
+
+	lines := make([]string, len(hits))
+	for i, r := range hits {
+		lines[i] = describeFilterRule(r)
+	}
+	ctx.Reply(truncate(fmt.Sprintf("🚫 Matches %d rule(s):\n%s\n%s", len(hits), strings.Join(lines, "\n"), normalized), 2000))
+}
@@ -0,0 +1,169 @@
+package bot
+
+import (
+	"discord-mod-bot/internal/config"
+	"discord-mod-bot/internal/store"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Free Nitro", "free nitro"},
+		{"Fr33 N1tr0", "free nitro"},
+		{"fr€€ n!tr0", "free nitro"},
+		{"Nítrо", "nitro"},     // accent and Cyrillic о
+		{"ｆｒｅｅ", "free"},       // full-width
+		{"fr\u200bee", "free"}, // zero-width space
+		{"ńitro", "nitro"},
+		{"$c@m", "scam"},
+		{"hello world", "hello world"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeText(tt.input); got != tt.want {
+			t.Errorf("normalizeText(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	// Without symbols, punctuation survives so it can end a word
+	if got := foldText("Scam! Fr33", false); got != "scam! free" {
+		t.Errorf("foldText without symbols = %q, want %q", got, "scam! free")
+	}
+}
+
+func TestCompileFilterRule(t *testing.T) {
+	tests := []struct {
+		kind    string
+		pattern string
+		matches []string
+		misses  []string
+		wantErr bool
+	}{
+		{
+			kind:    store.FilterWord,
+			pattern: "free nitro",
+			matches: []string{"free nitro", "get FREE   NITRO now", "fr33 n1tr0"},
+			misses:  []string{"freenitro", "free nitros", "carefree nitro"},
+		},
+		{
+			kind:    store.FilterWord,
+			pattern: "what?",
+			matches: []string{"so what? ok"},
+			misses:  []string{"so what ok", "so wha ok"},
+		},
+		{
+			kind:    store.FilterWildcard,
+			pattern: "sc*m",
+			matches: []string{"scam", "what a scheme scm", "sc4m"},
+			misses:  []string{"scams", "descam"},
+		},
+		{
+			kind:    store.FilterWildcard,
+			pattern: "n?tro",
+			matches: []string{"nitro", "natro"},
+			misses:  []string{"ntro", "niitro"},
+		},
+		{
+			kind:    store.FilterWildcard,
+			pattern: "a.b",
+			matches: []string{"a.b"},
+			misses:  []string{"axb"},
+		},
+		{
+			kind:    store.FilterRegex,
+			pattern: `steam\S*gift`,
+			matches: []string{"STEAMcommunity-gift", "steamgift"},
+			misses:  []string{"steam gift"},
+		},
+		{kind: store.FilterWord, pattern: "   ", wantErr: true},
+		{kind: store.FilterWildcard, pattern: "**?", wantErr: true},
+		{kind: store.FilterRegex, pattern: "(unclosed", wantErr: true},
+		{kind: "glob", pattern: "x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		re, err := compileFilterRule(tt.kind, tt.pattern)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("compileFilterRule(%s, %q) succeeded, want error", tt.kind, tt.pattern)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("compileFilterRule(%s, %q) error: %v", tt.kind, tt.pattern, err)
+			continue
+		}
+
+		for _, text := range tt.matches {
+			if !re.MatchString(normalizeText(text)) {
+				t.Errorf("%s %q doesn't match %q", tt.kind, tt.pattern, text)
+			}
+		}
+		for _, text := range tt.misses {
+			if re.MatchString(normalizeText(text)) {
+				t.Errorf("%s %q matches %q", tt.kind, tt.pattern, text)
+			}
+		}
+	}
+}
+
+func TestBlocklistMatches(t *testing.T) {
+	bl := &blocklist{}
+	bl.set([]*store.FilterRule{
+		{ID: 1, Kind: store.FilterWord, Pattern: "scam"},
+		{ID: 2, Kind: store.FilterWord, Pattern: "free nitro"},
+		{ID: 3, Kind: store.FilterRegex, Pattern: `discord\.gift/\w+`},
+		{ID: 4, Kind: store.FilterRegex, Pattern: "(broken"}, // skipped
+	})
+
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{"this is a scam!", []int{1}}, // ! stays punctuation
+		{"$c@m", []int{1}},            // but also reads as leetspeak
+		{"FREE NITRO scam", []int{1, 2}},
+		{"free nitro!", []int{2}},
+		{"visit discord.gift/AbC123", []int{3}},
+		{"scampi", nil},
+		{"", nil},
+		{"   ", nil},
+	}
+
+	for _, tt := range tests {
+		hits := bl.matches(tt.text, true)
+		var got []int
+		for _, r := range hits {
+			got = append(got, r.ID)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("matches(%q) = %v, want %v", tt.text, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("matches(%q) = %v, want %v", tt.text, got, tt.want)
+				break
+			}
+		}
+	}
+
+	if r := bl.match("FREE NITRO scam"); r == nil || r.ID != 1 {
+		t.Errorf("match returned %v, want the first rule", r)
+	}
+}
+
+func TestFilterNicknameSkipsUnchanged(t *testing.T) {
+	withConfig(t, &config.Config{AutomodEnabled: true})
+	b := &Bot{blocklist: &blocklist{}}
+	b.blocklist.set([]*store.FilterRule{{ID: 1, Kind: store.FilterWord, Pattern: "scam"}})
+
+	// A nil session panics if the nickname is acted on again
+	m := &discordgo.Member{GuildID: testGuildID, User: &discordgo.User{ID: "1"}, Nick: "scam bot"}
+	b.filterNickname(nil, m, "scam bot")
+}
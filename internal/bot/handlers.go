@@ -275,15 +275,15 @@ func (b *Bot) updateVanityRoleWithPresence(s *discordgo.Session, member *discord
 		log.Printf("Vanity: ✅ Successfully added role %s to user %s", vanityRole.Name, member.User.Username)
 
 		// Send log message
-		if config.Cfg.LogChannelID != "" {
-			embed := &discordgo.MessageEmbed{
-				Description: fmt.Sprintf("🩷 %s thanks for putting our vanity in your status, keep supporting!", vanityRole.Mention()),
-				Color:       0xFFC0CB, // Pink color
-			}
-			s.ChannelMessageSendComplex(config.Cfg.LogChannelID, &discordgo.MessageSend{
-				Content: member.User.Mention(),
-				Embed:   embed,
-			})
+		embed := &discordgo.MessageEmbed{
+			Description: fmt.Sprintf("🩷 %s thanks for putting our vanity in your status, keep supporting!", vanityRole.Mention()),
+			Color:       colorPink,
+		}
+		if _, err := sendLog(s, logVanity, &discordgo.MessageSend{
+			Content: member.User.Mention(),
+			Embed:   embed,
+		}); err != nil {
+			log.Printf("Vanity: Error sending log message: %v", err)
 		}
 	} else if !hasVanity && hasRole {
 		// Remove role if status doesn't contain vanity string and has role
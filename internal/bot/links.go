@@ -0,0 +1,294 @@
+package bot
+
+import (
+	"discord-mod-bot/internal/config"
+	"discord-mod-bot/internal/utils"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// linkPattern extracts the links urlPattern detects, up to the next space
+var linkPattern = regexp.MustCompile(`(?i)(?:https?://|www\.|discord\.gg/|discord(?:app)?\.com/)[^\s<>"'` + "`" + `]+`)
+
+// inviteCacheTTL is how long a resolved invite code is trusted
+const inviteCacheTTL = time.Hour
+
+// findLinks returns the links in content, with the scheme added where
+// it was left out
+func findLinks(content string) []*url.URL {
+	if !urlPattern.MatchString(content) {
+		return nil
+	}
+
+	var links []*url.URL
+	for _, raw := range linkPattern.FindAllString(content, -1) {
+		// Punctuation after a link usually belongs to the sentence
+		raw = strings.TrimRight(raw, ".,;:!?)]*_~|")
+		if !strings.Contains(strings.ToLower(raw), "://") {
+			raw = "http://" + raw
+		}
+
+		u, err := url.Parse(raw)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		links = append(links, u)
+	}
+	return links
+}
+
+// linkHost returns the lowercased host of u without a trailing dot
+func linkHost(u *url.URL) string {
+	return strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+}
+
+// hostMatches reports whether host is domain or one of its subdomains
+func hostMatches(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// inviteCode returns the invite code of a Discord invite link, or ""
+func inviteCode(u *url.URL) string {
+	host := linkHost(u)
+	path := strings.Trim(u.Path, "/")
+
+	switch {
+	case hostMatches(host, "discord.gg"):
+	case hostMatches(host, "discord.com"), hostMatches(host, "discordapp.com"):
+		var ok bool
+		if path, ok = strings.CutPrefix(path, "invite/"); !ok {
+			return ""
+		}
+	default:
+		return ""
+	}
+
+	code, _, _ := strings.Cut(path, "/")
+	return code
+}
+
+// linkRule is the domain allow and deny list of one channel ("*" for all)
+type linkRule struct {
+	Allow []string
+	Deny  []string
+}
+
+// parseLinkRules parses LINK_DOMAIN_RULES, e.g.
+// "*:deny:bit.ly,tinyurl.com;123456789:allow:youtube.com,twitch.tv"
+func parseLinkRules(raw string) (map[string]*linkRule, error) {
+	rules := make(map[string]*linkRule)
+
+	for _, part := range strings.Split(raw, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		fields := strings.SplitN(part, ":", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid link rule %q", part)
+		}
+
+		channelID := strings.TrimSpace(fields[0])
+		if channelID != "*" && !isSnowflake(channelID) {
+			return nil, fmt.Errorf("invalid channel %q in link rule %q", channelID, part)
+		}
+
+		var domains []string
+		for _, domain := range strings.Split(fields[2], ",") {
+			domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "www.")
+			if domain != "" {
+				domains = append(domains, domain)
+			}
+		}
+		if len(domains) == 0 {
+			return nil, fmt.Errorf("no domains in link rule %q", part)
+		}
+
+		rule := rules[channelID]
+		if rule == nil {
+			rule = &linkRule{}
+			rules[channelID] = rule
+		}
+
+		switch strings.ToLower(strings.TrimSpace(fields[1])) {
+		case "allow":
+			rule.Allow = append(rule.Allow, domains...)
+		case "deny":
+			rule.Deny = append(rule.Deny, domains...)
+		default:
+			return nil, fmt.Errorf("invalid list %q in link rule %q (use allow or deny)", fields[1], part)
+		}
+	}
+
+	return rules, nil
+}
+
+func listHas(domains []string, host string) bool {
+	for _, domain := range domains {
+		if hostMatches(host, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// domainAllowed applies the link rules of channelID and of all channels to
+// host. A channel's allow list wins over every deny list; a channel (or,
+// failing that, global) allow list blocks every domain not on it.
+func (b *Bot) domainAllowed(channelID, host string) bool {
+	channel := b.linkRules[channelID]
+	global := b.linkRules["*"]
+
+	if channel != nil && listHas(channel.Allow, host) {
+		return true
+	}
+	if (channel != nil && listHas(channel.Deny, host)) || (global != nil && listHas(global.Deny, host)) {
+		return false
+	}
+	if channel != nil && len(channel.Allow) > 0 {
+		return false
+	}
+	if global != nil && len(global.Allow) > 0 {
+		return listHas(global.Allow, host)
+	}
+	return true
+}
+
+// inviteCache remembers which guild invite codes lead to
+type inviteCache struct {
+	mu      sync.Mutex
+	entries map[string]inviteEntry
+}
+
+type inviteEntry struct {
+	guildID string // empty for invalid invites
+	at      time.Time
+}
+
+func newInviteCache() *inviteCache {
+	return &inviteCache{entries: make(map[string]inviteEntry)}
+}
+
+// resolve returns the guild an invite code leads to, "" for invalid or
+// expired invites
+func (ic *inviteCache) resolve(s *discordgo.Session, code string) (string, error) {
+	ic.mu.Lock()
+	entry, ok := ic.entries[code]
+	ic.mu.Unlock()
+	if ok && time.Since(entry.at) < inviteCacheTTL {
+		return entry.guildID, nil
+	}
+
+	guildID := ""
+	invite, err := s.Invite(code)
+	if err != nil {
+		var restErr *discordgo.RESTError
+		if !errors.As(err, &restErr) || restErr.Response == nil || restErr.Response.StatusCode != http.StatusNotFound {
+			return "", err
+		}
+	} else if invite.Guild != nil {
+		guildID = invite.Guild.ID
+	}
+
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	for c, e := range ic.entries {
+		if time.Since(e.at) >= inviteCacheTTL {
+			delete(ic.entries, c)
+		}
+	}
+	ic.entries[code] = inviteEntry{guildID: guildID, at: time.Now()}
+	return guildID, nil
+}
+
+// inviteAllowed reports whether an invite code leads to this guild or an
+// allowed one. Invalid invites lead nowhere and are allowed.
+func (b *Bot) inviteAllowed(s *discordgo.Session, code string) bool {
+	guildID, err := b.invites.resolve(s, code)
+	if err != nil {
+		// Don't delete messages because the API hiccupped
+		log.Printf("Links: Error resolving invite %s: %v", code, err)
+		return true
+	}
+	if guildID == "" || guildID == config.Cfg.GuildID {
+		return true
+	}
+	for _, allowed := range config.Cfg.LinkAllowedGuildIDs {
+		if guildID == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// linkExempt reports whether the author of m holds a LINK_EXEMPT_ROLE_IDS role
+func linkExempt(s *discordgo.Session, m *discordgo.Message) bool {
+	if len(config.Cfg.LinkExemptRoleIDs) == 0 {
+		return false
+	}
+
+	member := m.Member
+	if member == nil {
+		var err error
+		if member, err = utils.GetMember(s, m.GuildID, m.Author.ID); err != nil {
+			return false
+		}
+	}
+
+	for _, roleID := range member.Roles {
+		for _, exempt := range config.Cfg.LinkExemptRoleIDs {
+			if roleID == exempt {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkLinks is the automod check for invites to other servers and links
+// to domains the channel doesn't allow
+func (b *Bot) checkLinks(s *discordgo.Session, m *discordgo.Message) *automodViolation {
+	if !config.Cfg.LinkFilterEnabled {
+		return nil
+	}
+
+	links := findLinks(m.Content)
+	if len(links) == 0 || linkExempt(s, m) {
+		return nil
+	}
+
+	for _, u := range links {
+		host := strings.TrimPrefix(linkHost(u), "www.")
+		if !b.domainAllowed(m.ChannelID, host) {
+			return &automodViolation{
+				Rule:   "Link",
+				Reason: fmt.Sprintf("Posted a link to %s, which isn't allowed here", host),
+				Detail: truncate(u.String(), 1024),
+				Action: b.linkAction,
+			}
+		}
+
+		if !config.Cfg.LinkBlockInvites {
+			continue
+		}
+		if code := inviteCode(u); code != "" && !b.inviteAllowed(s, code) {
+			return &automodViolation{
+				Rule:   "Link",
+				Reason: "Posted an invite to another server",
+				Detail: fmt.Sprintf("Invite `%s`", code),
+				Action: b.linkAction,
+			}
+		}
+	}
+	return nil
+}
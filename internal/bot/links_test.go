@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestFindLinks(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"no links here", nil},
+		{"see https://example.com/page.", []string{"https://example.com/page"}},
+		{"(www.example.com)", []string{"http://www.example.com"}},
+		{"join discord.gg/abc123!", []string{"http://discord.gg/abc123"}},
+		{"<https://a.example> and HTTP://B.example/x", []string{"https://a.example", "http://B.example/x"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, u := range findLinks(tt.content) {
+			got = append(got, u.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("findLinks(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestInviteCode(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{"https://discord.gg/abc123", "abc123"},
+		{"http://DISCORD.GG/abc123/", "abc123"},
+		{"https://discord.com/invite/abc123", "abc123"},
+		{"https://discordapp.com/invite/abc123?event=1", "abc123"},
+		{"https://canary.discord.com/invite/abc123", "abc123"},
+		{"https://discord.com/channels/1/2", ""},
+		{"https://discord.com/invite", ""},
+		{"https://notdiscord.gg/abc123", ""},
+		{"https://example.com/invite/abc123", ""},
+	}
+
+	for _, tt := range tests {
+		u, err := url.Parse(tt.link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := inviteCode(u); got != tt.want {
+			t.Errorf("inviteCode(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestHostMatches(t *testing.T) {
+	tests := []struct {
+		host, domain string
+		want         bool
+	}{
+		{"youtube.com", "youtube.com", true},
+		{"m.youtube.com", "youtube.com", true},
+		{"notyoutube.com", "youtube.com", false},
+		{"youtube.com.evil.example", "youtube.com", false},
+		{"com", "youtube.com", false},
+	}
+
+	for _, tt := range tests {
+		if got := hostMatches(tt.host, tt.domain); got != tt.want {
+			t.Errorf("hostMatches(%q, %q) = %v, want %v", tt.host, tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestParseLinkRules(t *testing.T) {
+	rules, err := parseLinkRules(" *:deny:bit.ly, TinyURL.com ; 123456789012345695:allow:www.youtube.com,twitch.tv;123456789012345695:deny:clips.twitch.tv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]*linkRule{
+		"*":                  {Deny: []string{"bit.ly", "tinyurl.com"}},
+		"123456789012345695": {Allow: []string{"youtube.com", "twitch.tv"}, Deny: []string{"clips.twitch.tv"}},
+	}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("parseLinkRules = %+v, want %+v", rules, want)
+	}
+
+	if rules, err := parseLinkRules(""); err != nil || len(rules) != 0 {
+		t.Errorf("parseLinkRules(\"\") = %v, %v; want no rules", rules, err)
+	}
+
+	for _, raw := range []string{
+		"*:deny",
+		"general:deny:bit.ly",
+		"*:block:bit.ly",
+		"*:allow: , ",
+	} {
+		if _, err := parseLinkRules(raw); err == nil {
+			t.Errorf("parseLinkRules(%q) succeeded, want error", raw)
+		}
+	}
+}
+
+func TestDomainAllowed(t *testing.T) {
+	const media = "123456789012345695"
+	const general = "123456789012345696"
+	const memes = "123456789012345697"
+
+	tests := []struct {
+		name    string
+		rules   string
+		channel string
+		host    string
+		want    bool
+	}{
+		{"no rules", "", general, "example.com", true},
+		{"global deny", "*:deny:bit.ly", general, "bit.ly", false},
+		{"global deny covers subdomains", "*:deny:bit.ly", general, "x.bit.ly", false},
+		{"global deny leaves others", "*:deny:bit.ly", general, "example.com", true},
+		{"channel allow beats global deny", "*:deny:bit.ly;" + media + ":allow:bit.ly", media, "bit.ly", true},
+		{"channel allow blocks the rest", media + ":allow:youtube.com", media, "example.com", false},
+		{"channel allow covers subdomains", media + ":allow:youtube.com", media, "m.youtube.com", true},
+		{"channel allow stays in its channel", media + ":allow:youtube.com", general, "example.com", true},
+		{"channel deny", memes + ":deny:example.com", memes, "example.com", false},
+		{"channel deny beats global allow", "*:allow:example.com;" + memes + ":deny:example.com", memes, "example.com", false},
+		{"global allow blocks the rest", "*:allow:youtube.com", general, "example.com", false},
+		{"global allow", "*:allow:youtube.com", general, "youtube.com", true},
+		{"channel allow replaces global allow", "*:allow:youtube.com;" + media + ":allow:twitch.tv", media, "youtube.com", false},
+	}
+
+	for _, tt := range tests {
+		rules, err := parseLinkRules(tt.rules)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		b := &Bot{linkRules: rules}
+		if got := b.domainAllowed(tt.channel, tt.host); got != tt.want {
+			t.Errorf("%s: domainAllowed(%s) = %v, want %v", tt.name, tt.host, got, tt.want)
+		}
+	}
+}
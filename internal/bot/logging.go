@@ -0,0 +1,203 @@
+package bot
+
+import (
+	"discord-mod-bot/internal/config"
+	"discord-mod-bot/internal/store"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// logEvent is a kind of log message; each kind can go to its own channel
+type logEvent int
+
+const (
+	logModeration logEvent = iota // cases: bans, kicks, mutes, warnings...
+	logRoles                      // mod/staff/vanity role changes by command
+	logNicknames                  // nickname changes
+	logVanity                     // automatic vanity role assignments
+	logMessages                   // deleted and edited messages
+	logMembers                    // joins and leaves
+	logAutomod                    // messages removed by automod
+)
+
+// logChannel returns the channel for event, or "" if logging is off
+func logChannel(event logEvent) string {
+	var channelID string
+	switch event {
+	case logModeration:
+		channelID = config.Cfg.ModLogChannelID
+	case logRoles:
+		channelID = config.Cfg.RoleLogChannelID
+	case logNicknames:
+		channelID = config.Cfg.NickLogChannelID
+	case logVanity:
+		channelID = config.Cfg.VanityLogChannelID
+	case logMessages:
+		channelID = config.Cfg.MessageLogChannelID
+	case logMembers:
+		channelID = config.Cfg.MemberLogChannelID
+	case logAutomod:
+		channelID = config.Cfg.AutomodLogChannelID
+	}
+
+	if channelID == "" {
+		return config.Cfg.LogChannelID
+	}
+	return channelID
+}
+
+// sendLog posts msg to the channel for event. It returns nil, nil if no
+// channel is configured.
+func sendLog(s *discordgo.Session, event logEvent, msg *discordgo.MessageSend) (*discordgo.Message, error) {
+	channelID := logChannel(event)
+	if channelID == "" {
+		return nil, nil
+	}
+	return s.ChannelMessageSendComplex(channelID, msg)
+}
+
+// Embed colors of the log messages
+const (
+	colorRed    = 0xED4245
+	colorOrange = 0xE67E22
+	colorYellow = 0xFEE75C
+	colorGreen  = 0x57F287
+	colorBlue   = 0x5865F2
+	colorPink   = 0xFFC0CB
+	colorGray   = 0x99AAB5
+)
+
+// caseColors are the log embed colors used for each case action
+var caseColors = map[string]int{
+	store.ActionBan:     colorRed,
+	store.ActionTempban: colorRed,
+	store.ActionKick:    colorOrange,
+	store.ActionMute:    colorYellow,
+	store.ActionUnban:   colorGreen,
+	store.ActionUnmute:  colorGreen,
+	store.ActionWarn:    colorYellow,
+
+	store.ActionQuarantine: colorRed,
+}
+
+// loggedAction is a non-case event posted by logAction
+type loggedAction struct {
+	Title string
+	Color int
+	Event logEvent
+}
+
+// Actions logged without a case
+var (
+	logModRoleAdded      = loggedAction{"👤 Mod Role Added", colorGreen, logRoles}
+	logModRoleRemoved    = loggedAction{"👤 Mod Role Removed", colorOrange, logRoles}
+	logStaffRoleAdded    = loggedAction{"👥 Staff Role Added", colorGreen, logRoles}
+	logStaffRoleRemoved  = loggedAction{"👥 Staff Role Removed", colorOrange, logRoles}
+	logVanityRoleAdded   = loggedAction{"⭐ Vanity Role Added", colorGreen, logRoles}
+	logVanityRoleRemoved = loggedAction{"⭐ Vanity Role Removed", colorOrange, logRoles}
+	logNicknameChanged   = loggedAction{"📝 Nickname Changed", colorBlue, logNicknames}
+	logNicknameReset     = loggedAction{"📝 Nickname Reset", colorBlue, logNicknames}
+	logWarningRemoved    = loggedAction{"🗑️ Warning Removed", colorGray, logModeration}
+	logRolesUpdated      = loggedAction{"🎭 Roles Updated", colorBlue, logRoles}
+	logStickyRestored    = loggedAction{"📌 Sticky Roles Restored", colorBlue, logRoles}
+)
+
+// truncate shortens s to at most max runes for embed limits
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-3]) + "..."
+}
+
+// userField renders a user as mention, name and ID. target may be nil when
+// the user couldn't be fetched.
+func userField(name, userID string, target *discordgo.User) *discordgo.MessageEmbedField {
+	value := fmt.Sprintf("<@%s>", userID)
+	if target != nil {
+		value += fmt.Sprintf("\n%s (`%s`)", target.Username, userID)
+	} else {
+		value += fmt.Sprintf("\n`%s`", userID)
+	}
+	return &discordgo.MessageEmbedField{Name: name, Value: value, Inline: true}
+}
+
+// actionEmbed builds the common log embed: target with avatar, moderator
+// and reason. Only the target is fetched; the moderator shows as a mention.
+func actionEmbed(s *discordgo.Session, title string, color int, moderatorID, targetID, reason string) *discordgo.MessageEmbed {
+	target, _ := s.User(targetID)
+
+	embed := &discordgo.MessageEmbed{
+		Title: title,
+		Color: color,
+		Fields: []*discordgo.MessageEmbedField{
+			userField("Target", targetID, target),
+			{Name: "Moderator", Value: fmt.Sprintf("<@%s>", moderatorID), Inline: true},
+		},
+		Footer:    &discordgo.MessageEmbedFooter{Text: "User ID: " + targetID},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	if target != nil {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: target.AvatarURL("128")}
+	}
+
+	if reason != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Reason", Value: truncate(reason, 1024), Inline: false})
+	}
+
+	return embed
+}
+
+// caseLogEmbed builds the log embed for a case
+func caseLogEmbed(s *discordgo.Session, c *store.Case) *discordgo.MessageEmbed {
+	title := caseLabel(c.Action)
+	if c.ID != 0 {
+		title += fmt.Sprintf(" | Case #%d", c.ID)
+	}
+
+	color, ok := caseColors[c.Action]
+	if !ok {
+		color = colorBlue
+	}
+
+	reason := c.Reason
+	if reason == "" {
+		reason = "No reason provided"
+	}
+
+	embed := actionEmbed(s, strings.ReplaceAll(title, "**", ""), color, c.ModeratorID, c.TargetID, reason)
+	if !c.CreatedAt.IsZero() {
+		embed.Timestamp = c.CreatedAt.Format(time.RFC3339)
+	}
+	if c.ID != 0 {
+		embed.Footer.Text = fmt.Sprintf("Case #%d • User ID: %s", c.ID, c.TargetID)
+	}
+
+	if !c.ExpiresAt.IsZero() {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Expires",
+			Value:  fmt.Sprintf("<t:%d:f> (<t:%d:R>)", c.ExpiresAt.Unix(), c.ExpiresAt.Unix()),
+			Inline: false,
+		})
+	}
+
+	return embed
+}
+
+// logAction posts a non-case event to its log channel
+func (b *Bot) logAction(s *discordgo.Session, action loggedAction, moderatorID, targetID, reason string) {
+	if logChannel(action.Event) == "" {
+		return // No log channel configured
+	}
+
+	embed := actionEmbed(s, action.Title, action.Color, moderatorID, targetID, reason)
+	if _, err := sendLog(s, action.Event, &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}}); err != nil {
+		log.Printf("Error sending log message: %v", err)
+	}
+}
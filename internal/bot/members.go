@@ -0,0 +1,316 @@
+package bot
+
+import (
+	"discord-mod-bot/internal/config"
+	"discord-mod-bot/internal/store"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// memberSnapshot is what the bot last saw of a member. discordgo drops a
+// member from the state cache before GuildMemberRemove handlers run, so
+// leave logs need their own copy.
+type memberSnapshot struct {
+	JoinedAt time.Time
+	Nick     string
+	Roles    []string
+}
+
+// memberTracker keeps a snapshot of every member of the guild
+type memberTracker struct {
+	mu      sync.Mutex
+	members map[string]memberSnapshot
+}
+
+func newMemberTracker() *memberTracker {
+	return &memberTracker{members: make(map[string]memberSnapshot)}
+}
+
+func (mt *memberTracker) set(m *discordgo.Member) {
+	if m == nil || m.User == nil {
+		return
+	}
+
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	mt.members[m.User.ID] = memberSnapshot{
+		JoinedAt: m.JoinedAt,
+		Nick:     m.Nick,
+		Roles:    append([]string(nil), m.Roles...),
+	}
+}
+
+func (mt *memberTracker) get(userID string) (memberSnapshot, bool) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	snap, ok := mt.members[userID]
+	return snap, ok
+}
+
+// take removes and returns the snapshot of userID
+func (mt *memberTracker) take(userID string) (memberSnapshot, bool) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	snap, ok := mt.members[userID]
+	delete(mt.members, userID)
+	return snap, ok
+}
+
+func (b *Bot) onGuildCreate(s *discordgo.Session, e *discordgo.GuildCreate) {
+	if e.Guild == nil || e.ID != config.Cfg.GuildID {
+		return
+	}
+
+	for _, m := range e.Members {
+		b.members.set(m)
+	}
+
+	// Large guilds only send part of the member list up front
+	if err := s.RequestGuildMembers(e.ID, "", 0, "", false); err != nil {
+		log.Printf("Members: Error requesting member list: %v", err)
+	}
+}
+
+func (b *Bot) onGuildMembersChunk(s *discordgo.Session, e *discordgo.GuildMembersChunk) {
+	if e.GuildID != config.Cfg.GuildID {
+		return
+	}
+	for _, m := range e.Members {
+		b.members.set(m)
+	}
+}
+
+func (b *Bot) onGuildMemberAdd(s *discordgo.Session, e *discordgo.GuildMemberAdd) {
+	if e.Member == nil || e.User == nil || e.GuildID != config.Cfg.GuildID {
+		return
+	}
+
+	b.members.set(e.Member)
+	b.logMemberJoin(s, e.Member)
+	b.restoreStickyRoles(s, e.GuildID, e.User.ID)
+}
+
+// onGuildMemberRemove logs the leave and picks out kicks made outside the bot
+func (b *Bot) onGuildMemberRemove(s *discordgo.Session, e *discordgo.GuildMemberRemove) {
+	if e.Member == nil || e.User == nil || e.GuildID != config.Cfg.GuildID {
+		return
+	}
+
+	snap, known := b.members.take(e.User.ID)
+	departure := &store.Departure{
+		UserID:   e.User.ID,
+		JoinedAt: snap.JoinedAt,
+		LeftAt:   time.Now(),
+		Roles:    stickyRoles(snap.Roles),
+	}
+	if err := b.store.RecordDeparture(departure); err != nil {
+		log.Printf("Members: Error recording departure of %s: %v", e.User.ID, err)
+	}
+
+	b.logMemberLeave(s, e.User, snap, known)
+	b.reconcileKick(s, e.GuildID, e.User.ID)
+}
+
+func (b *Bot) onGuildMemberUpdate(s *discordgo.Session, e *discordgo.GuildMemberUpdate) {
+	if e.Member == nil || e.User == nil || e.GuildID != config.Cfg.GuildID {
+		return
+	}
+
+	// The state cache provides the roles and nickname before the update;
+	// fall back to the tracker if the member wasn't cached
+	var before []string
+	var beforeNick string
+	known := true
+	if e.BeforeUpdate != nil {
+		before, beforeNick = e.BeforeUpdate.Roles, e.BeforeUpdate.Nick
+	} else if snap, ok := b.members.get(e.User.ID); ok {
+		before, beforeNick = snap.Roles, snap.Nick
+	} else {
+		known = false
+	}
+	b.members.set(e.Member)
+	b.filterNickname(s, e.Member, beforeNick)
+
+	// Without the previous roles every role would look newly added
+	if !known {
+		return
+	}
+
+	added, removed := diffRoles(before, e.Roles)
+	if len(added) > 0 || len(removed) > 0 {
+		b.reconcileRoles(s, e.GuildID, e.User.ID, added, removed)
+	}
+}
+
+// diffRoles returns the roles in after but not before, and the reverse
+func diffRoles(before, after []string) (added, removed []string) {
+	had := make(map[string]bool, len(before))
+	for _, roleID := range before {
+		had[roleID] = true
+	}
+	has := make(map[string]bool, len(after))
+	for _, roleID := range after {
+		has[roleID] = true
+		if !had[roleID] {
+			added = append(added, roleID)
+		}
+	}
+	for _, roleID := range before {
+		if !has[roleID] {
+			removed = append(removed, roleID)
+		}
+	}
+	return added, removed
+}
+
+// accountCreated returns when the account with the given ID was created
+func accountCreated(userID string) time.Time {
+	created, err := discordgo.SnowflakeTimestamp(userID)
+	if err != nil {
+		return time.Time{}
+	}
+	return created
+}
+
+// isNewAccount reports whether the account is younger than NEW_ACCOUNT_DAYS
+func isNewAccount(userID string) bool {
+	if config.Cfg.NewAccountDays <= 0 {
+		return false
+	}
+	created := accountCreated(userID)
+	return !created.IsZero() && time.Since(created) < time.Duration(config.Cfg.NewAccountDays)*24*time.Hour
+}
+
+// priorCasesField summarizes the cases against userID by action
+func (b *Bot) priorCasesField(userID string) *discordgo.MessageEmbedField {
+	value := "None"
+	cases, err := b.store.CasesForUser(userID)
+	if err != nil {
+		log.Printf("Members: Error loading cases for user %s: %v", userID, err)
+		value = "Unavailable"
+	} else if len(cases) > 0 {
+		counts := make(map[string]int)
+		var actions []string
+		for _, c := range cases {
+			if counts[c.Action] == 0 {
+				actions = append(actions, c.Action)
+			}
+			counts[c.Action]++
+		}
+
+		var parts []string
+		for _, action := range actions {
+			parts = append(parts, fmt.Sprintf("%d× %s", counts[action], caseLabel(action)))
+		}
+		value = fmt.Sprintf("**%d** case(s)\n%s", len(cases), strings.Join(parts, "\n"))
+	}
+	return &discordgo.MessageEmbedField{Name: "Prior Cases", Value: value, Inline: true}
+}
+
+// memberLogEmbed builds the common part of the join and leave logs
+func memberLogEmbed(user *discordgo.User, title string, color int) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       title,
+		Description: fmt.Sprintf("<@%s> %s (`%s`)", user.ID, user.Username, user.ID),
+		Color:       color,
+		Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: user.AvatarURL("128")},
+		Footer:      &discordgo.MessageEmbedFooter{Text: "User ID: " + user.ID},
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+
+	if created := accountCreated(user.ID); !created.IsZero() {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Account Created",
+			Value:  fmt.Sprintf("<t:%d:f> (<t:%d:R>)", created.Unix(), created.Unix()),
+			Inline: true,
+		})
+	}
+	return embed
+}
+
+func (b *Bot) logMemberJoin(s *discordgo.Session, member *discordgo.Member) {
+	if logChannel(logMembers) == "" {
+		return
+	}
+
+	user := member.User
+	embed := memberLogEmbed(user, "📥 Member Joined", colorGreen)
+
+	if isNewAccount(user.ID) {
+		embed.Title += " • ⚠️ New Account"
+		embed.Color = colorOrange
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "⚠️ New Account",
+			Value:  fmt.Sprintf("Created less than %d day(s) ago", config.Cfg.NewAccountDays),
+			Inline: true,
+		})
+	}
+
+	embed.Fields = append(embed.Fields, b.priorCasesField(user.ID))
+
+	rejoin := "No"
+	last, err := b.store.LastDeparture(user.ID)
+	if err == nil {
+		rejoin = fmt.Sprintf("Yes, %d time(s)\nLast left <t:%d:R>", last.Count, last.LeftAt.Unix())
+	} else if !errors.Is(err, store.ErrNotFound) {
+		log.Printf("Members: Error loading departures for user %s: %v", user.ID, err)
+		rejoin = "Unknown"
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Previously Left", Value: rejoin, Inline: true})
+
+	if _, err := sendLog(s, logMembers, &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}}); err != nil {
+		log.Printf("Members: Error logging join of %s: %v", user.ID, err)
+	}
+}
+
+// logMemberLeave logs a departure. snap is the member as last seen, if known.
+func (b *Bot) logMemberLeave(s *discordgo.Session, user *discordgo.User, snap memberSnapshot, known bool) {
+	if logChannel(logMembers) == "" {
+		return
+	}
+
+	now := time.Now()
+
+	embed := memberLogEmbed(user, "📤 Member Left", colorGray)
+
+	if known && !snap.JoinedAt.IsZero() {
+		stayed := now.Sub(snap.JoinedAt)
+		if stayed >= time.Minute {
+			stayed = stayed.Truncate(time.Minute)
+		}
+		embed.Fields = append(embed.Fields,
+			&discordgo.MessageEmbedField{
+				Name:   "Joined",
+				Value:  fmt.Sprintf("<t:%d:f>", snap.JoinedAt.Unix()),
+				Inline: true,
+			},
+			&discordgo.MessageEmbedField{
+				Name:   "Time in Server",
+				Value:  formatDuration(stayed),
+				Inline: true,
+			},
+		)
+	}
+
+	if known && len(snap.Roles) > 0 {
+		roles := make([]string, len(snap.Roles))
+		for i, roleID := range snap.Roles {
+			roles[i] = fmt.Sprintf("<@&%s>", roleID)
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: "Roles", Value: truncate(strings.Join(roles, " "), 1024), Inline: false,
+		})
+	}
+
+	embed.Fields = append(embed.Fields, b.priorCasesField(user.ID))
+
+	if _, err := sendLog(s, logMembers, &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}}); err != nil {
+		log.Printf("Members: Error logging leave of %s: %v", user.ID, err)
+	}
+}
@@ -0,0 +1,231 @@
+package bot
+
+import (
+	"discord-mod-bot/internal/config"
+	"discord-mod-bot/internal/utils"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// everyonePattern finds attempts to ping @everyone or @here, which Discord
+// reports in MentionEveryone only when the author is allowed to. Code spans
+// and \@everyone don't ping, so they are left alone.
+var everyonePattern = regexp.MustCompile(`(?:^|[^\w\\` + "`" + `])@(?:everyone|here)\b`)
+
+// mentionLimits are the MENTION_* thresholds; a limit of 0 turns that check off
+type mentionLimits struct {
+	Message int
+	Window  int
+	Period  time.Duration
+}
+
+func currentMentionLimits() mentionLimits {
+	return mentionLimits{
+		Message: config.Cfg.MentionMessageLimit,
+		Window:  config.Cfg.MentionWindowLimit,
+		Period:  time.Duration(config.Cfg.MentionWindow) * time.Second,
+	}
+}
+
+// parseMentionExempt turns MENTION_EXEMPT_ROLES, e.g. "admin,staff,123456789",
+// into role IDs. The names admin, staff and mod stand for the configured tier
+// roles; "none" exempts nobody.
+func parseMentionExempt(raw string) ([]string, error) {
+	var roleIDs []string
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		roleID := entry
+		switch strings.ToLower(entry) {
+		case "", "none":
+			continue
+		case utils.RoleAdmin:
+			roleID = config.Cfg.AdminRoleID
+		case utils.RoleStaff:
+			roleID = config.Cfg.StaffRoleID
+		case utils.RoleMod:
+			roleID = config.Cfg.ModRoleID
+		default:
+			if !isSnowflake(entry) {
+				return nil, fmt.Errorf("invalid role %q (use %s, %s, %s or a role ID)", entry, utils.RoleAdmin, utils.RoleStaff, utils.RoleMod)
+			}
+		}
+		// Tiers without a configured role have nobody to exempt
+		if roleID != "" {
+			roleIDs = append(roleIDs, roleID)
+		}
+	}
+	return roleIDs, nil
+}
+
+// mentionTargets returns the unique users and roles m pings, keyed so a
+// user and a role with the same ID stay apart
+func mentionTargets(m *discordgo.Message) []string {
+	seen := make(map[string]bool)
+	var targets []string
+	add := func(key string) {
+		if !seen[key] {
+			seen[key] = true
+			targets = append(targets, key)
+		}
+	}
+
+	for _, user := range m.Mentions {
+		if user.ID != m.Author.ID {
+			add("user:" + user.ID)
+		}
+	}
+	for _, roleID := range m.MentionRoles {
+		add("role:" + roleID)
+	}
+	return targets
+}
+
+// mentionTracker keeps each user's recent mentions in a sliding window
+type mentionTracker struct {
+	mu        sync.Mutex
+	history   map[string][]mentionEntry // userID -> recent messages with mentions, oldest first
+	lastSweep time.Time
+}
+
+type mentionEntry struct {
+	ref     messageRef
+	targets []string
+	at      time.Time
+}
+
+func newMentionTracker() *mentionTracker {
+	return &mentionTracker{history: make(map[string][]mentionEntry)}
+}
+
+// record adds a message for userID and counts the unique targets pinged
+// within the window. On a trip it returns the messages that did the pinging
+// and the count, and clears the user's history so one wave only triggers once.
+func (t *mentionTracker) record(userID string, e mentionEntry, period time.Duration, limit int) ([]messageRef, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Users who stopped pinging would otherwise stay in the map forever
+	if e.at.Sub(t.lastSweep) > time.Minute {
+		for id, entries := range t.history {
+			if e.at.Sub(entries[len(entries)-1].at) > period {
+				delete(t.history, id)
+			}
+		}
+		t.lastSweep = e.at
+	}
+
+	recent := t.history[userID][:0:0]
+	for _, old := range t.history[userID] {
+		if e.at.Sub(old.at) <= period {
+			recent = append(recent, old)
+		}
+	}
+	recent = append(recent, e)
+
+	unique := make(map[string]bool)
+	for _, old := range recent {
+		for _, target := range old.targets {
+			unique[target] = true
+		}
+	}
+
+	if len(unique) >= limit {
+		refs := make([]messageRef, 0, len(recent))
+		for _, old := range recent {
+			refs = append(refs, old.ref)
+		}
+		delete(t.history, userID)
+		return refs, len(unique)
+	}
+
+	t.history[userID] = recent
+	return nil, 0
+}
+
+// exemptFromMentions reports whether the author of m holds a MENTION_EXEMPT_ROLES role
+func (b *Bot) exemptFromMentions(s *discordgo.Session, m *discordgo.Message) bool {
+	if len(b.mentionExempt) == 0 {
+		return false
+	}
+
+	member := m.Member
+	if member == nil {
+		var err error
+		if member, err = utils.GetMember(s, m.GuildID, m.Author.ID); err != nil {
+			return false
+		}
+	}
+
+	for _, roleID := range member.Roles {
+		for _, exempt := range b.mentionExempt {
+			if roleID == exempt {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkMentions is the automod check for mass mentions and @everyone pings
+func (b *Bot) checkMentions(s *discordgo.Session, m *discordgo.Message) *automodViolation {
+	targets := mentionTargets(m)
+	everyone := config.Cfg.MentionBlockEveryone && (m.MentionEveryone || everyonePattern.MatchString(m.Content))
+	if len(targets) == 0 && !everyone {
+		return nil
+	}
+
+	if b.exemptFromMentions(s, m) {
+		return nil
+	}
+
+	if everyone {
+		return &automodViolation{
+			Rule:   "Mentions",
+			Reason: "Tried to ping @everyone or @here",
+			Action: b.mentionAction,
+		}
+	}
+
+	limits := currentMentionLimits()
+	if limits.Message > 0 && len(targets) >= limits.Message {
+		roles := 0
+		for _, target := range targets {
+			if strings.HasPrefix(target, "role:") {
+				roles++
+			}
+		}
+		return &automodViolation{
+			Rule:   "Mentions",
+			Reason: fmt.Sprintf("Mentioned %d users and roles in one message", len(targets)),
+			Detail: fmt.Sprintf("%d user(s), %d role(s)", len(targets)-roles, roles),
+			Action: b.mentionAction,
+		}
+	}
+
+	if limits.Window <= 0 || len(targets) == 0 {
+		return nil
+	}
+
+	entry := mentionEntry{
+		ref:     messageRef{ChannelID: m.ChannelID, ID: m.ID},
+		targets: targets,
+		at:      time.Now(),
+	}
+	wave, count := b.mentions.record(m.Author.ID, entry, limits.Period, limits.Window)
+	if wave == nil {
+		return nil
+	}
+
+	return &automodViolation{
+		Rule:     "Mentions",
+		Reason:   fmt.Sprintf("Mentioned %d users and roles within %s", count, formatDuration(limits.Period)),
+		Detail:   fmt.Sprintf("%d message(s)", len(wave)),
+		Action:   b.mentionAction,
+		Messages: wave,
+	}
+}
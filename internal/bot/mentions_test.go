@@ -0,0 +1,247 @@
+package bot
+
+import (
+	"discord-mod-bot/internal/config"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestParseMentionExempt(t *testing.T) {
+	withConfig(t, &config.Config{
+		AdminRoleID: "200000000000000010",
+		ModRoleID:   "200000000000000030",
+	})
+
+	tests := []struct {
+		raw     string
+		want    []string
+		wantErr bool
+	}{
+		{raw: "", want: nil},
+		{raw: "none", want: nil},
+		{raw: "Admin, mod", want: []string{"200000000000000010", "200000000000000030"}},
+		{raw: "staff", want: nil}, // no staff role configured
+		{raw: "admin,123456789012345678", want: []string{"200000000000000010", "123456789012345678"}},
+		{raw: "moderators", wantErr: true},
+		{raw: "admin,12345", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseMentionExempt(tt.raw)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseMentionExempt(%q) succeeded, want error", tt.raw)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseMentionExempt(%q) error: %v", tt.raw, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseMentionExempt(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestMentionTargets(t *testing.T) {
+	m := &discordgo.Message{
+		Author: &discordgo.User{ID: "1"},
+		Mentions: []*discordgo.User{
+			{ID: "1"}, // the author
+			{ID: "2"},
+			{ID: "3"},
+			{ID: "2"},
+		},
+		MentionRoles: []string{"2", "4", "4"},
+	}
+
+	want := []string{"user:2", "user:3", "role:2", "role:4"}
+	if got := mentionTargets(m); !reflect.DeepEqual(got, want) {
+		t.Errorf("mentionTargets = %q, want %q", got, want)
+	}
+}
+
+func TestCheckMentionsDetail(t *testing.T) {
+	withConfig(t, &config.Config{MentionMessageLimit: 3})
+	b := &Bot{mentions: newMentionTracker()}
+
+	// Repeated mentions are counted once
+	m := &discordgo.Message{
+		Author:       &discordgo.User{ID: "1"},
+		Mentions:     []*discordgo.User{{ID: "2"}, {ID: "2"}},
+		MentionRoles: []string{"4", "4", "4", "5"},
+	}
+	v := b.checkMentions(nil, m)
+	if v == nil {
+		t.Fatal("no violation")
+	}
+	if want := "1 user(s), 2 role(s)"; v.Detail != want {
+		t.Errorf("Detail = %q, want %q", v.Detail, want)
+	}
+}
+
+func TestEveryonePattern(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"@everyone", true},
+		{"hey @here look", true},
+		{"(@everyone)", true},
+		{"mail me at me@everyone.com", false},
+		{`\@everyone`, false},
+		{"`@everyone`", false},
+		{"@everyones", false},
+		{"@Everyone", false},
+		{"everyone here", false},
+	}
+
+	for _, tt := range tests {
+		if got := everyonePattern.MatchString(tt.content); got != tt.want {
+			t.Errorf("everyonePattern.MatchString(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+// mentionMessage is one message pinging targets at offset from the start of a test
+type mentionMessage struct {
+	user    string
+	targets []string
+	offset  time.Duration
+}
+
+func TestMentionTrackerRecord(t *testing.T) {
+	const period = 10 * time.Second
+	const limit = 4
+
+	tests := []struct {
+		name     string
+		messages []mentionMessage
+		tripAt   int // index of the message that trips, -1 for none
+		count    int
+		wave     int
+	}{
+		{
+			name: "wave",
+			messages: []mentionMessage{
+				{"u", []string{"user:1", "user:2"}, 0},
+				{"u", []string{"user:3"}, time.Second},
+				{"u", []string{"role:4"}, 2 * time.Second},
+			},
+			tripAt: 2,
+			count:  4,
+			wave:   3,
+		},
+		{
+			name: "repeat pings count once",
+			messages: []mentionMessage{
+				{"u", []string{"user:1", "user:2"}, 0},
+				{"u", []string{"user:1", "user:2"}, time.Second},
+				{"u", []string{"user:2", "user:3"}, 2 * time.Second},
+			},
+			tripAt: -1,
+		},
+		{
+			name: "pings outside the window expire",
+			messages: []mentionMessage{
+				{"u", []string{"user:1", "user:2"}, 0},
+				{"u", []string{"user:3"}, 6 * time.Second},
+				{"u", []string{"user:4"}, 12 * time.Second},
+			},
+			tripAt: -1,
+		},
+		{
+			name: "window edge is inclusive",
+			messages: []mentionMessage{
+				{"u", []string{"user:1", "user:2"}, 0},
+				{"u", []string{"user:3", "user:4"}, period},
+			},
+			tripAt: 1,
+			count:  4,
+			wave:   2,
+		},
+		{
+			name: "users are counted apart",
+			messages: []mentionMessage{
+				{"u", []string{"user:1", "user:2"}, 0},
+				{"v", []string{"user:3", "user:4"}, time.Second},
+			},
+			tripAt: -1,
+		},
+		{
+			name: "one message over the limit",
+			messages: []mentionMessage{
+				{"u", []string{"user:1", "user:2", "user:3", "role:1", "role:2"}, 0},
+			},
+			tripAt: 0,
+			count:  5,
+			wave:   1,
+		},
+	}
+
+	start := time.Now()
+	for _, tt := range tests {
+		tracker := newMentionTracker()
+		tripped := -1
+		for i, m := range tt.messages {
+			e := mentionEntry{
+				ref:     messageRef{ChannelID: "c", ID: fmt.Sprint(i)},
+				targets: m.targets,
+				at:      start.Add(m.offset),
+			}
+			wave, count := tracker.record(m.user, e, period, limit)
+			if wave == nil {
+				continue
+			}
+			if tripped >= 0 {
+				t.Errorf("%s: tripped again at message %d", tt.name, i)
+			}
+			tripped = i
+			if count != tt.count {
+				t.Errorf("%s: counted %d targets, want %d", tt.name, count, tt.count)
+			}
+			if len(wave) != tt.wave {
+				t.Errorf("%s: wave of %d messages, want %d", tt.name, len(wave), tt.wave)
+			}
+		}
+		if tripped != tt.tripAt {
+			t.Errorf("%s: tripped at message %d, want %d", tt.name, tripped, tt.tripAt)
+		}
+	}
+}
+
+func TestMentionTrackerResetsAfterTrip(t *testing.T) {
+	tracker := newMentionTracker()
+	start := time.Now()
+
+	e := mentionEntry{targets: []string{"user:1", "user:2"}, at: start}
+	if wave, _ := tracker.record("u", e, time.Minute, 2); wave == nil {
+		t.Fatal("first wave didn't trip")
+	}
+
+	// The same targets again start a new count rather than tripping on old history
+	e = mentionEntry{targets: []string{"user:1"}, at: start.Add(time.Second)}
+	if wave, _ := tracker.record("u", e, time.Minute, 2); wave != nil {
+		t.Error("tripped on history from before the last trip")
+	}
+}
+
+func TestMentionTrackerSweep(t *testing.T) {
+	tracker := newMentionTracker()
+	start := time.Now()
+
+	tracker.record("gone", mentionEntry{targets: []string{"user:1"}, at: start}, 10*time.Second, 10)
+	tracker.record("active", mentionEntry{targets: []string{"user:1"}, at: start.Add(2 * time.Minute)}, 10*time.Second, 10)
+
+	if _, ok := tracker.history["gone"]; ok {
+		t.Error("history of an inactive user was kept")
+	}
+	if _, ok := tracker.history["active"]; !ok {
+		t.Error("history of an active user was dropped")
+	}
+}
@@ -0,0 +1,226 @@
+package bot
+
+import (
+	"bytes"
+	"discord-mod-bot/internal/config"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// cacheMessage remembers a guild message so its deletion or edit can be logged
+func (b *Bot) cacheMessage(m *discordgo.Message) {
+	if m.GuildID != config.Cfg.GuildID || m.Author == nil || m.Author.Bot {
+		return
+	}
+	b.messages.add(newCachedMessage(m))
+}
+
+// ignoreMessageLog reports whether events in channelID shouldn't be logged,
+// so the bot doesn't log deletions in its own message-log channel
+func ignoreMessageLog(guildID, channelID string) bool {
+	return guildID != config.Cfg.GuildID || channelID == logChannel(logMessages)
+}
+
+// attachmentList renders attachment names for a log field
+func attachmentList(names []string) string {
+	return truncate(strings.Join(names, "\n"), 1024)
+}
+
+// contentField renders message content for a log field
+func contentField(name, content string) *discordgo.MessageEmbedField {
+	if content == "" {
+		content = "*No text content*"
+	}
+	return &discordgo.MessageEmbedField{Name: name, Value: truncate(content, 1024), Inline: false}
+}
+
+func (b *Bot) onMessageDelete(s *discordgo.Session, e *discordgo.MessageDelete) {
+	if ignoreMessageLog(e.GuildID, e.ChannelID) {
+		return
+	}
+
+	cached := b.messages.remove(e.ID)
+	if b.messages.wasPurged(e.ID) || logChannel(logMessages) == "" {
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:     "🗑️ Message Deleted",
+		Color:     colorRed,
+		Footer:    &discordgo.MessageEmbedFooter{Text: "Message ID: " + e.ID},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	if cached == nil {
+		embed.Description = fmt.Sprintf("A message in <#%s> was deleted, but it wasn't cached so its content is unknown.", e.ChannelID)
+	} else {
+		embed.Description = truncate(cached.Content, 4096)
+		if embed.Description == "" {
+			embed.Description = "*No text content*"
+		}
+		embed.Fields = []*discordgo.MessageEmbedField{
+			{Name: "Author", Value: fmt.Sprintf("<@%s>\n%s (`%s`)", cached.AuthorID, cached.AuthorName, cached.AuthorID), Inline: true},
+			{Name: "Channel", Value: fmt.Sprintf("<#%s>", cached.ChannelID), Inline: true},
+			{Name: "Sent", Value: fmt.Sprintf("<t:%d:f>", cached.CreatedAt.Unix()), Inline: true},
+		}
+		if len(cached.Attachments) > 0 {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+				Name: "Attachments", Value: attachmentList(cached.Attachments), Inline: false,
+			})
+		}
+	}
+
+	if _, err := sendLog(s, logMessages, &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}}); err != nil {
+		log.Printf("MessageLog: Error logging deleted message %s: %v", e.ID, err)
+	}
+}
+
+func (b *Bot) onMessageDeleteBulk(s *discordgo.Session, e *discordgo.MessageDeleteBulk) {
+	if ignoreMessageLog(e.GuildID, e.ChannelID) {
+		return
+	}
+
+	var cached []*cachedMessage
+	count := 0
+	for _, id := range e.Messages {
+		m := b.messages.remove(id)
+		if b.messages.wasPurged(id) {
+			continue
+		}
+		count++
+		if m != nil {
+			cached = append(cached, m)
+		}
+	}
+	if count == 0 || logChannel(logMessages) == "" {
+		return
+	}
+
+	b.logDeletedMessages(s, e.ChannelID, count, cached)
+}
+
+// logDeletedMessages posts a summary of several deleted messages with a
+// transcript of those that were cached
+func (b *Bot) logDeletedMessages(s *discordgo.Session, channelID string, count int, cached []*cachedMessage) {
+	description := fmt.Sprintf("**%d** messages were deleted in <#%s>.", count, channelID)
+	if len(cached) < count {
+		description += fmt.Sprintf("\n%d of them weren't cached and are missing from the transcript.", count-len(cached))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "🗑️ Messages Bulk Deleted",
+		Description: description,
+		Color:       colorRed,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+
+	msg := &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}}
+	if len(cached) > 0 {
+		msg.Files = []*discordgo.File{transcriptFile(channelID, cached)}
+	}
+
+	if _, err := sendLog(s, logMessages, msg); err != nil {
+		log.Printf("MessageLog: Error logging %d deleted messages in %s: %v", count, channelID, err)
+	}
+}
+
+// transcriptFile attaches the transcript of messages deleted in channelID
+func transcriptFile(channelID string, messages []*cachedMessage) *discordgo.File {
+	return &discordgo.File{
+		Name:        fmt.Sprintf("transcript-%s-%d.txt", channelID, time.Now().Unix()),
+		ContentType: "text/plain",
+		Reader:      strings.NewReader(messageTranscript(messages)),
+	}
+}
+
+// messageTranscript renders messages as plain text, oldest first
+func messageTranscript(messages []*cachedMessage) string {
+	sorted := append([]*cachedMessage(nil), messages...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+
+	var buf bytes.Buffer
+	for _, m := range sorted {
+		fmt.Fprintf(&buf, "[%s] %s (%s): %s\n", m.CreatedAt.UTC().Format("2006-01-02 15:04:05"), m.AuthorName, m.AuthorID, m.Content)
+		for _, name := range m.Attachments {
+			fmt.Fprintf(&buf, "    [attachment] %s\n", name)
+		}
+	}
+	return buf.String()
+}
+
+func (b *Bot) onMessageUpdate(s *discordgo.Session, e *discordgo.MessageUpdate) {
+	if e.Message == nil || ignoreMessageLog(e.GuildID, e.ChannelID) {
+		return
+	}
+	if e.Author != nil && e.Author.Bot {
+		return
+	}
+
+	// Embed unfurls and pins also fire updates; only edits set EditedTimestamp
+	if e.EditedTimestamp == nil {
+		return
+	}
+
+	// Editing a message must not get it past the filters
+	if b.runAutomod(s, e.Message, true) {
+		return
+	}
+
+	before := b.messages.get(e.ID)
+	after := newCachedMessage(e.Message)
+	if before != nil {
+		// Updates can be partial, so keep what the edit didn't send
+		if after.AuthorID == "" {
+			after.AuthorID, after.AuthorName = before.AuthorID, before.AuthorName
+		}
+		after.CreatedAt = before.CreatedAt
+		if before.Content == after.Content {
+			b.messages.add(after)
+			return
+		}
+	}
+	if after.AuthorID != "" {
+		b.messages.add(after)
+	}
+
+	if logChannel(logMessages) == "" {
+		return
+	}
+
+	jump := fmt.Sprintf("https://discord.com/channels/%s/%s/%s", e.GuildID, e.ChannelID, e.ID)
+	embed := &discordgo.MessageEmbed{
+		Title:       "✏️ Message Edited",
+		Description: fmt.Sprintf("[Jump to message](%s)", jump),
+		Color:       colorBlue,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Message ID: " + e.ID},
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+
+	if before != nil {
+		embed.Fields = append(embed.Fields, contentField("Before", before.Content))
+	} else {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Before", Value: "*Not cached*", Inline: false})
+	}
+	embed.Fields = append(embed.Fields, contentField("After", after.Content))
+
+	if after.AuthorID != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: "Author", Value: fmt.Sprintf("<@%s>\n%s (`%s`)", after.AuthorID, after.AuthorName, after.AuthorID), Inline: true,
+		})
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Channel", Value: fmt.Sprintf("<#%s>", e.ChannelID), Inline: true})
+	if len(after.Attachments) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: "Attachments", Value: attachmentList(after.Attachments), Inline: false,
+		})
+	}
+
+	if _, err := sendLog(s, logMessages, &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}}); err != nil {
+		log.Printf("MessageLog: Error logging edited message %s: %v", e.ID, err)
+	}
+}
@@ -0,0 +1,202 @@
+package bot
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const messageCacheFile = "messages.json"
+
+// cachedMessage is the part of a message needed to log its deletion or edit
+type cachedMessage struct {
+	ID          string    `json:"id"`
+	ChannelID   string    `json:"channel_id"`
+	GuildID     string    `json:"guild_id"`
+	AuthorID    string    `json:"author_id"`
+	AuthorName  string    `json:"author_name"`
+	Content     string    `json:"content"`
+	Attachments []string  `json:"attachments,omitempty"` // file names
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func newCachedMessage(m *discordgo.Message) *cachedMessage {
+	c := &cachedMessage{
+		ID:        m.ID,
+		ChannelID: m.ChannelID,
+		GuildID:   m.GuildID,
+		Content:   m.Content,
+		CreatedAt: m.Timestamp,
+	}
+	if m.Author != nil {
+		c.AuthorID = m.Author.ID
+		c.AuthorName = m.Author.Username
+	}
+	for _, a := range m.Attachments {
+		c.Attachments = append(c.Attachments, a.Filename)
+	}
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now()
+	}
+	return c
+}
+
+// messageCache keeps the most recent messages, dropping the oldest once
+// it holds max of them. With a path it can be saved to and loaded from disk.
+type messageCache struct {
+	mu    sync.Mutex
+	path  string // empty if not persisted
+	max   int
+	order *list.List // oldest first, values are *cachedMessage
+	byID  map[string]*list.Element
+
+	// Messages the bot deletes itself and logs on its own, by ID
+	purged map[string]time.Time
+}
+
+func newMessageCache(max int, path string) *messageCache {
+	if max <= 0 {
+		max = 1
+	}
+	return &messageCache{
+		path:   path,
+		max:    max,
+		order:  list.New(),
+		byID:   make(map[string]*list.Element),
+		purged: make(map[string]time.Time),
+	}
+}
+
+// add stores m, replacing an older copy with the same ID
+func (mc *messageCache) add(m *cachedMessage) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if el, ok := mc.byID[m.ID]; ok {
+		el.Value = m
+		return
+	}
+
+	mc.byID[m.ID] = mc.order.PushBack(m)
+	for mc.order.Len() > mc.max {
+		oldest := mc.order.Front()
+		mc.order.Remove(oldest)
+		delete(mc.byID, oldest.Value.(*cachedMessage).ID)
+	}
+}
+
+// get returns a copy of the cached message, or nil
+func (mc *messageCache) get(id string) *cachedMessage {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	el, ok := mc.byID[id]
+	if !ok {
+		return nil
+	}
+	c := *el.Value.(*cachedMessage)
+	return &c
+}
+
+// remove drops the message and returns it, or nil if it wasn't cached
+func (mc *messageCache) remove(id string) *cachedMessage {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	el, ok := mc.byID[id]
+	if !ok {
+		return nil
+	}
+	mc.order.Remove(el)
+	delete(mc.byID, id)
+	return el.Value.(*cachedMessage)
+}
+
+// markPurged notes that the bot is about to delete ids and logs them itself,
+// so the delete events don't get logged a second time
+func (mc *messageCache) markPurged(ids []string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	now := time.Now()
+	for id, at := range mc.purged {
+		// Deletes that never produced an event
+		if now.Sub(at) > time.Minute {
+			delete(mc.purged, id)
+		}
+	}
+	for _, id := range ids {
+		mc.purged[id] = now
+	}
+}
+
+// wasPurged reports whether id was marked with markPurged, and forgets it
+func (mc *messageCache) wasPurged(id string) bool {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	_, ok := mc.purged[id]
+	delete(mc.purged, id)
+	return ok
+}
+
+// load reads a cache saved by save; a missing file is not an error
+func (mc *messageCache) load() error {
+	if mc.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(mc.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", mc.path, err)
+	}
+
+	var messages []*cachedMessage
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return fmt.Errorf("error parsing %s: %w", mc.path, err)
+	}
+
+	for _, m := range messages {
+		mc.add(m)
+	}
+	return nil
+}
+
+// save writes the cache to disk, oldest first
+func (mc *messageCache) save() error {
+	if mc.path == "" {
+		return nil
+	}
+
+	mc.mu.Lock()
+	messages := make([]*cachedMessage, 0, mc.order.Len())
+	for el := mc.order.Front(); el != nil; el = el.Next() {
+		messages = append(messages, el.Value.(*cachedMessage))
+	}
+	data, err := json.Marshal(messages)
+	mc.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(mc.path), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temp file first so a crash never leaves a truncated file
+	tmp := mc.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, mc.path)
+}
@@ -0,0 +1,279 @@
+package bot
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"golang.org/x/net/idna"
+)
+
+const (
+	// phishingReloadInterval is how often the domain list file is checked
+	// for changes
+	phishingReloadInterval = 30 * time.Second
+	// maxRedirectDepth bounds how many redirect wrappers are unwrapped
+	maxRedirectDepth = 3
+)
+
+// phishingList is a set of known phishing domains loaded from a file, one
+// domain per line. The file is re-read when it changes, so the list can be
+// updated without restarting the bot.
+type phishingList struct {
+	mu      sync.RWMutex
+	path    string
+	domains map[string]bool // ASCII (punycode) form
+	modTime time.Time
+	checked time.Time
+}
+
+func newPhishingList(path string) *phishingList {
+	return &phishingList{path: path, domains: make(map[string]bool)}
+}
+
+// reload re-reads the file if it changed. Unless force is set it looks at
+// the file at most every phishingReloadInterval.
+func (pl *phishingList) reload(force bool) error {
+	pl.mu.Lock()
+	if !force && time.Since(pl.checked) < phishingReloadInterval {
+		pl.mu.Unlock()
+		return nil
+	}
+	pl.checked = time.Now()
+	loaded := pl.modTime
+	pl.mu.Unlock()
+
+	info, err := os.Stat(pl.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(loaded) {
+		return nil
+	}
+
+	data, err := os.ReadFile(pl.path)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", pl.path, err)
+	}
+
+	domains := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if domain := listedDomain(line); domain != "" {
+			domains[domain] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading %s: %w", pl.path, err)
+	}
+
+	pl.mu.Lock()
+	pl.domains = domains
+	pl.modTime = info.ModTime()
+	pl.mu.Unlock()
+
+	log.Printf("Phishing: Loaded %d domain(s) from %s", len(domains), pl.path)
+	return nil
+}
+
+// listedDomain turns a line of the list into a domain. Lines may also be
+// URLs or wildcards like *.example.com.
+func listedDomain(line string) string {
+	line = strings.Fields(line)[0]
+	if strings.Contains(line, "://") {
+		if u, err := url.Parse(line); err == nil {
+			line = u.Hostname()
+		}
+	}
+	line = strings.TrimPrefix(line, "*.")
+	line, _, _ = strings.Cut(line, "/")
+	return asciiHost(strings.Trim(strings.ToLower(line), "."))
+}
+
+// match returns the listed domain host belongs to, or ""
+func (pl *phishingList) match(host string) string {
+	pl.mu.RLock()
+	defer pl.mu.RUnlock()
+
+	if len(pl.domains) == 0 {
+		return ""
+	}
+
+	for _, form := range hostForms(host) {
+		// Check the host and each parent domain
+		for domain := form; domain != ""; {
+			if pl.domains[domain] {
+				return domain
+			}
+			_, parent, ok := strings.Cut(domain, ".")
+			if !ok {
+				break
+			}
+			domain = parent
+		}
+	}
+	return ""
+}
+
+// hostForms returns the punycode form of host and, for internationalized
+// hosts, the form with look-alike letters folded to ASCII, so a listed
+// steamcommunity.gift also catches the same name spelled with Cyrillic letters
+func hostForms(host string) []string {
+	ascii := asciiHost(host)
+	forms := []string{ascii}
+
+	unicodeForm := unicodeHost(ascii)
+	if unicodeForm != ascii {
+		var folded strings.Builder
+		for _, r := range unicodeForm {
+			if r >= 0x80 {
+				folded.WriteString(foldText(string(r), false))
+				continue
+			}
+			folded.WriteRune(r)
+		}
+		if f := folded.String(); f != ascii {
+			forms = append(forms, f)
+		}
+	}
+	return forms
+}
+
+// asciiHost converts every non-ASCII label of host to punycode
+func asciiHost(host string) string {
+	labels := strings.Split(strings.ToLower(host), ".")
+	for i, label := range labels {
+		if isASCII(label) {
+			continue
+		}
+		if encoded, err := idna.Punycode.ToASCII(label); err == nil {
+			labels[i] = encoded
+		}
+	}
+	return strings.Join(labels, ".")
+}
+
+// unicodeHost decodes the punycode labels of host; invalid ones are kept
+func unicodeHost(host string) string {
+	labels := strings.Split(host, ".")
+	for i, label := range labels {
+		if !strings.HasPrefix(label, "xn--") {
+			continue
+		}
+		if decoded, err := idna.Punycode.ToUnicode(label); err == nil {
+			labels[i] = strings.ToLower(decoded)
+		}
+	}
+	return strings.Join(labels, ".")
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// unwrapRedirects returns u and every URL wrapped inside it by redirectors
+// like google.com/url?q=..., steamcommunity.com/linkfilter/?url=... or
+// href.li/?https://...
+func unwrapRedirects(u *url.URL) []*url.URL {
+	found := []*url.URL{u}
+	queue := []*url.URL{u}
+	for depth := 0; depth < maxRedirectDepth && len(queue) > 0; depth++ {
+		var next []*url.URL
+		for _, wrapper := range queue {
+			targets := redirectTargets(wrapper)
+			found = append(found, targets...)
+			next = append(next, targets...)
+		}
+		queue = next
+	}
+	return found
+}
+
+// redirectTargets returns the URLs embedded in the query or path of u
+func redirectTargets(u *url.URL) []*url.URL {
+	var candidates []string
+	for _, values := range u.Query() {
+		candidates = append(candidates, values...)
+	}
+	if raw, err := url.QueryUnescape(u.RawQuery); err == nil {
+		candidates = append(candidates, raw)
+	}
+	if i := strings.Index(strings.ToLower(u.Path), "http"); i > 0 {
+		candidates = append(candidates, u.Path[i:])
+	}
+
+	var targets []*url.URL
+	for _, c := range candidates {
+		lower := strings.ToLower(c)
+		if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") &&
+			!strings.HasPrefix(lower, "http:/") && !strings.HasPrefix(lower, "https:/") {
+			continue
+		}
+		// Paths collapse // to /, so http:/example.com is restored
+		if !strings.Contains(lower, "://") {
+			c = strings.Replace(c, ":/", "://", 1)
+		}
+		if target, err := url.Parse(c); err == nil && target.Hostname() != "" {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// defang renders a URL as code so it isn't clickable in the log
+func defang(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "'") + "`"
+}
+
+// checkPhishing is the automod check for links to known phishing domains
+func (b *Bot) checkPhishing(s *discordgo.Session, m *discordgo.Message) *automodViolation {
+	if b.phishing == nil {
+		return nil
+	}
+
+	links := findLinks(m.Content)
+	if len(links) == 0 {
+		return nil
+	}
+
+	if err := b.phishing.reload(false); err != nil {
+		log.Printf("Phishing: Error reloading domain list: %v", err)
+	}
+
+	for _, link := range links {
+		for _, u := range unwrapRedirects(link) {
+			domain := b.phishing.match(linkHost(u))
+			if domain == "" {
+				continue
+			}
+
+			detail := defang(u.String())
+			if u != link {
+				detail += "\nvia " + defang(link.String())
+			}
+			return &automodViolation{
+				Rule:   "Phishing",
+				Reason: fmt.Sprintf("Posted a link to the known phishing domain %s", defang(domain)),
+				Detail: detail,
+				Action: b.phishingAction,
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHostPunycodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		unicode string
+		ascii   string
+	}{
+		{"münchen.de", "xn--mnchen-3ya.de"},
+		{"bücher.example", "xn--bcher-kva.example"},
+		{"ѕteamcommunity.com", "xn--teamcommunity-ohl.com"},
+		{"example.com", "example.com"},
+	}
+
+	for _, tt := range tests {
+		if got := asciiHost(tt.unicode); got != tt.ascii {
+			t.Errorf("asciiHost(%q) = %q, want %q", tt.unicode, got, tt.ascii)
+		}
+		if got := unicodeHost(tt.ascii); got != tt.unicode {
+			t.Errorf("unicodeHost(%q) = %q, want %q", tt.ascii, got, tt.unicode)
+		}
+	}
+}
+
+func TestUnicodeHostInvalidPunycode(t *testing.T) {
+	// Used to overflow the decoder and panic
+	for _, host := range []string{
+		"xn--00000000000000000x0000000000000000000z.com",
+		"xn--.com",
+		"xn--99999999999999999999.example",
+		"xn---.example",
+	} {
+		unicodeHost(host)
+		hostForms(host)
+	}
+}
+
+func TestPhishingListMatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "phishing.txt")
+	list := "# comment\n\nsteamcommunity.gift\nhttps://discord-nitro.ru/claim\n*.bad.example\nmünchen.de\n"
+	if err := os.WriteFile(path, []byte(list), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pl := newPhishingList(path)
+	if err := pl.reload(true); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"steamcommunity.gift", "steamcommunity.gift"},
+		{"login.steamcommunity.gift", "steamcommunity.gift"},
+		{"discord-nitro.ru", "discord-nitro.ru"},
+		{"x.bad.example", "bad.example"},
+		{"münchen.de", "xn--mnchen-3ya.de"},
+		{"xn--mnchen-3ya.de", "xn--mnchen-3ya.de"},
+		{"ѕteamcommunity.gift", "steamcommunity.gift"}, // Cyrillic ѕ
+		{"steamcommunity.com", ""},
+		{"gift", ""},
+		{"notsteamcommunity.gift", ""},
+	}
+
+	for _, tt := range tests {
+		if got := pl.match(tt.host); got != tt.want {
+			t.Errorf("match(%q) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestUnwrapRedirects(t *testing.T) {
+	tests := []struct {
+		link string
+		want string // innermost host
+	}{
+		{"https://www.google.com/url?q=https://steamcommunity.gift/x", "steamcommunity.gift"},
+		{"https://steamcommunity.com/linkfilter/?url=https%3A%2F%2Fdiscord-nitro.ru", "discord-nitro.ru"},
+		{"https://href.li/?https://bad.example/a", "bad.example"},
+		{"https://redirect.example/go/https:/bad.example/a", "bad.example"},
+		{"https://www.google.com/url?q=https%3A%2F%2Fhref.li%2F%3Fhttps%3A%2F%2Fbad.example", "bad.example"},
+	}
+
+	for _, tt := range tests {
+		u, err := url.Parse(tt.link)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		found := false
+		for _, target := range unwrapRedirects(u) {
+			if linkHost(target) == tt.want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("unwrapRedirects(%q) doesn't contain %s", tt.link, tt.want)
+		}
+	}
+}
+
+func FuzzHostForms(f *testing.F) {
+	f.Add("xn--00000000000000000x0000000000000000000z")
+	f.Add("xn--mnchen-3ya.de")
+	f.Add("münchen.de")
+	f.Fuzz(func(t *testing.T, host string) {
+		hostForms(host)
+	})
+}
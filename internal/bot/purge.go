@@ -0,0 +1,327 @@
+package bot
+
+import (
+	"discord-mod-bot/internal/utils"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// purgeMaxCount is the most messages one !purge deletes
+	purgeMaxCount = 500
+	// purgeScanLimit is how far back !purge looks for matching messages
+	purgeScanLimit = 2000
+	// bulkDeleteMaxAge is how old a message may be for bulk deletion; Discord
+	// refuses anything older than 14 days, so keep a margin
+	bulkDeleteMaxAge = 14*24*time.Hour - time.Hour
+	// bulkDeleteBatch is the most messages one bulk delete request takes
+	bulkDeleteBatch = 100
+)
+
+// purgeFilter selects which messages !purge deletes. The zero value
+// matches everything.
+type purgeFilter struct {
+	UserID      string
+	Bots        bool
+	Contains    string // lowercase
+	Links       bool
+	Attachments bool
+	AfterID     string // only messages newer than this one
+}
+
+// purgeFilterHelp is shown in !help and the slash command option
+const purgeFilterHelp = "@user, bots, links, attachments, contains <text>, after <message ID>"
+
+// parsePurgeFilter parses the filter words of !purge
+func parsePurgeFilter(ctx *commandContext, raw string) (*purgeFilter, *argError) {
+	f := &purgeFilter{}
+	if strings.TrimSpace(raw) == "" {
+		return f, nil
+	}
+
+	tokens, err := splitArgs(raw)
+	if err != nil {
+		return nil, err.(*argError)
+	}
+
+	// next returns the value following a keyword
+	next := func(i int, keyword string) (string, *argError) {
+		if i+1 >= len(tokens) {
+			return "", &argError{Kind: argErrInvalid, Reason: fmt.Sprintf("`%s` needs a value.", keyword)}
+		}
+		return tokens[i+1].Value, nil
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		word := tokens[i].Value
+		switch strings.ToLower(word) {
+		case "bots", "bot":
+			f.Bots = true
+		case "links", "link":
+			f.Links = true
+		case "attachments", "attachment", "files", "images":
+			f.Attachments = true
+		case "contains":
+			value, err := next(i, "contains")
+			if err != nil {
+				return nil, err
+			}
+			f.Contains = strings.ToLower(value)
+			i++
+		case "after", "since":
+			value, err := next(i, word)
+			if err != nil {
+				return nil, err
+			}
+			if !isSnowflake(value) {
+				return nil, &argError{Kind: argErrInvalid, Reason: fmt.Sprintf("`%s` is not a message ID.", value)}
+			}
+			f.AfterID = value
+			i++
+		case "user", "from":
+			value, err := next(i, word)
+			if err != nil {
+				return nil, err
+			}
+			userID, argErr := resolveUser(ctx.Session, ctx.GuildID, value)
+			if argErr != nil {
+				return nil, argErr
+			}
+			f.UserID = userID
+			i++
+		default:
+			// A bare mention or ID filters by user
+			if userID := parseUserID(word); userID != "" {
+				f.UserID = userID
+				continue
+			}
+			return nil, &argError{Kind: argErrInvalid, Reason: fmt.Sprintf("Unknown filter `%s`. Filters: %s.", word, purgeFilterHelp)}
+		}
+	}
+
+	return f, nil
+}
+
+func (f *purgeFilter) matches(m *discordgo.Message) bool {
+	if m.Author == nil {
+		return false
+	}
+	if f.UserID != "" && m.Author.ID != f.UserID {
+		return false
+	}
+	if f.Bots && !m.Author.Bot {
+		return false
+	}
+	if f.Contains != "" && !strings.Contains(strings.ToLower(m.Content), f.Contains) {
+		return false
+	}
+	if f.Links && !urlPattern.MatchString(m.Content) {
+		return false
+	}
+	if f.Attachments && len(m.Attachments) == 0 {
+		return false
+	}
+	return true
+}
+
+// String describes the filter for replies and logs
+func (f *purgeFilter) String() string {
+	var parts []string
+	if f.UserID != "" {
+		parts = append(parts, fmt.Sprintf("from <@%s>", f.UserID))
+	}
+	if f.Bots {
+		parts = append(parts, "from bots")
+	}
+	if f.Contains != "" {
+		parts = append(parts, fmt.Sprintf("containing \"%s\"", f.Contains))
+	}
+	if f.Links {
+		parts = append(parts, "with links")
+	}
+	if f.Attachments {
+		parts = append(parts, "with attachments")
+	}
+	if f.AfterID != "" {
+		parts = append(parts, fmt.Sprintf("after message %s", f.AfterID))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// snowflakeAfter reports whether ID a is newer than ID b
+func snowflakeAfter(a, b string) bool {
+	x, errA := strconv.ParseUint(a, 10, 64)
+	y, errB := strconv.ParseUint(b, 10, 64)
+	return errA == nil && errB == nil && x > y
+}
+
+// collectPurge walks the channel history from before (exclusive, "" for the
+// newest message) and returns up to count messages matching f. tooOld is
+// true if the walk stopped at the 14-day bulk delete limit.
+func collectPurge(s *discordgo.Session, channelID, before string, count int, f *purgeFilter) (matched []*discordgo.Message, tooOld bool, err error) {
+	scanned := 0
+	for len(matched) < count && scanned < purgeScanLimit {
+		batch, err := s.ChannelMessages(channelID, 100, before, "", "")
+		if err != nil {
+			return matched, false, err
+		}
+		if len(batch) == 0 {
+			return matched, false, nil
+		}
+
+		for _, m := range batch {
+			scanned++
+			if f.AfterID != "" && !snowflakeAfter(m.ID, f.AfterID) {
+				return matched, false, nil
+			}
+			if time.Since(m.Timestamp) > bulkDeleteMaxAge {
+				return matched, true, nil
+			}
+			if m.Pinned || !f.matches(m) {
+				continue
+			}
+
+			matched = append(matched, m)
+			if len(matched) == count {
+				return matched, false, nil
+			}
+		}
+		before = batch[len(batch)-1].ID
+	}
+	return matched, false, nil
+}
+
+func (b *Bot) handlePurge(ctx *commandContext, args commandArgs) {
+	s := ctx.Session
+	count := args.Int("count")
+	if count > purgeMaxCount {
+		ctx.Reply(fmt.Sprintf("❌ You can purge at most %d messages at once.", purgeMaxCount))
+		return
+	}
+
+	filter, argErr := parsePurgeFilter(ctx, args.String("filters"))
+	if argErr != nil {
+		if cmd := b.commands.lookup("purge"); cmd != nil {
+			argErr.Usage = cmd.usageText()
+		}
+		ctx.Reply(argErr.Error())
+		return
+	}
+
+	quota, ok := checkQuota(ctx, utils.ActionPurge)
+	if !ok {
+		return
+	}
+
+	// Start above the command itself, or above the deferred slash response
+	// so the reply survives; the command message is deleted separately
+	before := ""
+	if !ctx.isSlash() {
+		before = ctx.message.ID
+	} else if response, err := s.InteractionResponse(ctx.interaction); err == nil {
+		before = response.ID
+	} else {
+		log.Printf("Purge: Error getting deferred response: %v", err)
+	}
+
+	matched, tooOld, err := collectPurge(s, ctx.ChannelID, before, count, filter)
+	if err != nil && len(matched) == 0 {
+		quota.Cancel()
+		log.Printf("Purge: Error reading messages in %s: %v", ctx.ChannelID, err)
+		ctx.Reply("❌ Failed to read messages. Check the bot has **Read Message History** permission.")
+		return
+	}
+
+	if len(matched) == 0 {
+		quota.Cancel()
+		msg := "❌ No matching messages found."
+		if tooOld {
+			msg += " Messages older than 14 days can't be purged."
+		}
+		ctx.Reply(msg)
+		return
+	}
+
+	ids := make([]string, len(matched))
+	for i, m := range matched {
+		ids[i] = m.ID
+	}
+	b.messages.markPurged(ids)
+
+	deleted := 0
+	for start := 0; start < len(ids); start += bulkDeleteBatch {
+		end := start + bulkDeleteBatch
+		if end > len(ids) {
+			end = len(ids)
+		}
+		if err := s.ChannelMessagesBulkDelete(ctx.ChannelID, ids[start:end]); err != nil {
+			log.Printf("Purge: Error deleting messages in %s: %v", ctx.ChannelID, err)
+			break
+		}
+		deleted = end
+	}
+
+	if deleted == 0 {
+		quota.Cancel()
+		ctx.Reply("❌ Failed to delete messages. Check the bot has **Manage Messages** permission.")
+		return
+	}
+
+	if !ctx.isSlash() {
+		if err := s.ChannelMessageDelete(ctx.ChannelID, ctx.message.ID); err != nil {
+			log.Printf("Purge: Error deleting command message: %v", err)
+		}
+	}
+
+	log.Printf("Purge: %s deleted %d message(s) in %s (filters: %s)", ctx.Author.ID, deleted, ctx.ChannelID, filter)
+	b.logPurge(s, ctx, matched[:deleted], filter)
+
+	reply := fmt.Sprintf("✅ Deleted %d message(s).", deleted)
+	if deleted < len(matched) {
+		reply += fmt.Sprintf(" %d could not be deleted.", len(matched)-deleted)
+	}
+	if tooOld {
+		reply += " Messages older than 14 days were skipped."
+	}
+	ctx.Reply(reply)
+}
+
+// logPurge posts the purge with a transcript of the deleted messages
+func (b *Bot) logPurge(s *discordgo.Session, ctx *commandContext, deleted []*discordgo.Message, filter *purgeFilter) {
+	if logChannel(logModeration) == "" {
+		return
+	}
+
+	transcript := make([]*cachedMessage, len(deleted))
+	for i, m := range deleted {
+		transcript[i] = newCachedMessage(m)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "🧹 Messages Purged",
+		Color: colorOrange,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Moderator", Value: fmt.Sprintf("<@%s>", ctx.Author.ID), Inline: true},
+			{Name: "Channel", Value: fmt.Sprintf("<#%s>", ctx.ChannelID), Inline: true},
+			{Name: "Deleted", Value: strconv.Itoa(len(deleted)), Inline: true},
+			{Name: "Filters", Value: truncate(filter.String(), 1024), Inline: false},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	_, err := sendLog(s, logModeration, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{embed},
+		Files:  []*discordgo.File{transcriptFile(ctx.ChannelID, transcript)},
+	})
+	if err != nil {
+		log.Printf("Purge: Error sending log message: %v", err)
+	}
+}
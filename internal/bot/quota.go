@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"discord-mod-bot/internal/config"
+	"discord-mod-bot/internal/utils"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// checkQuota enforces the invoking moderator's quota for action and counts
+// the action against it. Returns false after replying if the action must
+// not go ahead; otherwise the caller cancels the record if the action fails.
+func checkQuota(ctx *commandContext, action string) (*utils.ModActionRecord, bool) {
+	record, err := utils.CheckAndRecordModAction(ctx.Session, ctx.GuildID, ctx.Author.ID, action)
+	if err == nil {
+		return record, true
+	}
+
+	var quotaErr *utils.QuotaExceededError
+	if errors.As(err, &quotaErr) {
+		msg := fmt.Sprintf("❌ %s limit reached (%s).", capitalize(action), quotaErr.Limit)
+		if !quotaErr.RetryAt.IsZero() {
+			msg += fmt.Sprintf(" Next one available <t:%d:R>.", quotaErr.RetryAt.Unix())
+		}
+		ctx.Reply(msg)
+		return nil, false
+	}
+
+	log.Printf("Quota: Error checking %s quota for user %s: %v", action, ctx.Author.ID, err)
+	ctx.Reply("❌ Could not verify your moderation quota, try again later.")
+	return nil, false
+}
+
+func (b *Bot) handleQuota(ctx *commandContext, args commandArgs) {
+	s := ctx.Session
+
+	// Admin/staff may look up someone else's budget
+	userID := ctx.Author.ID
+	if args.Has("user") {
+		if memberTier(s, ctx.GuildID, ctx.Author.ID) < tierStaff {
+			ctx.Reply("❌ Only Admin/Staff can view other moderators' quotas.")
+			return
+		}
+		userID = args.User("user")
+	}
+
+	tier, usage, err := utils.GetQuotaUsage(s, ctx.GuildID, userID)
+	if err != nil {
+		log.Printf("Quota: Error loading quota for user %s: %v", userID, err)
+		ctx.Reply("❌ Failed to load quota.")
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "📊 Moderation Quota",
+		Color: 0x5865F2,
+	}
+
+	if tier == "" || len(usage) == 0 {
+		embed.Description = fmt.Sprintf("<@%s> has no moderation limits.", userID)
+		ctx.ReplyEmbed(embed)
+		return
+	}
+
+	switch tier {
+	case utils.RoleMod:
+		tier = "Moderator"
+	case utils.RoleStaff:
+		tier = "Staff"
+	default:
+		tier = fmt.Sprintf("<@&%s>", tier)
+	}
+
+	var lines []string
+	for _, u := range usage {
+		line := fmt.Sprintf("**%s:** %d left (%s)", capitalize(u.Action), u.Remaining, u.Limit)
+		if u.Remaining == 0 && !u.ResetAt.IsZero() {
+			line += fmt.Sprintf(" • next <t:%d:R>", u.ResetAt.Unix())
+		}
+		lines = append(lines, line)
+	}
+
+	embed.Description = fmt.Sprintf("Quota for <@%s> (tier: %s)\n\n%s\n\nActions without a listed limit are unlimited.",
+		userID, tier, strings.Join(lines, "\n"))
+	embed.Footer = &discordgo.MessageEmbedFooter{Text: "Limits use rolling windows • " + config.Cfg.Prefix + "quota"}
+
+	ctx.ReplyEmbed(embed)
+}
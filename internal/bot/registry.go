@@ -0,0 +1,203 @@
+package bot
+
+import (
+	"discord-mod-bot/internal/config"
+	"discord-mod-bot/internal/utils"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// tier is the minimum role a command requires
+type tier int
+
+const (
+	tierEveryone tier = iota
+	tierMod           // Admin, Mod or Staff
+	tierStaff         // Admin or Staff
+)
+
+func (t tier) String() string {
+	switch t {
+	case tierMod:
+		return "Admin/Mod/Staff"
+	case tierStaff:
+		return "Admin/Staff"
+	default:
+		return "Everyone"
+	}
+}
+
+// memberTier returns the highest tier the user holds
+func memberTier(s *discordgo.Session, guildID, userID string) tier {
+	hasAdmin, _ := utils.HasPermission(s, guildID, userID, utils.RoleAdmin)
+	hasStaff, _ := utils.HasPermission(s, guildID, userID, utils.RoleStaff)
+	if hasAdmin || hasStaff {
+		return tierStaff
+	}
+
+	hasMod, _ := utils.HasPermission(s, guildID, userID, utils.RoleMod)
+	if hasMod {
+		return tierMod
+	}
+	return tierEveryone
+}
+
+// argKind is the type of a command argument
+type argKind int
+
+const (
+	argUser     argKind = iota // user mention, ID or name
+	argRole                    // role mention, ID or name
+	argChannel                 // channel mention, ID or name
+	argWord                    // single word or "quoted text"
+	argInt                     // positive integer, "#12" is accepted
+	argDuration                // human duration like 1d12h
+	argChoice                  // one of argSpec.Choices; subcommands on slash
+	argRest                    // all remaining words
+)
+
+// argSpec describes one positional argument of a command
+type argSpec struct {
+	Name        string
+	Kind        argKind
+	Description string   // shown for slash command options
+	Optional    bool     // optional typed args that don't parse are skipped
+	Default     string   // value of an omitted optional argRest/argWord
+	Choices     []string // argChoice only
+	Invalid     string   // replaces the parser's reason when the value doesn't parse
+}
+
+// usage renders the argument as <name> or [name]
+func (a argSpec) usage() string {
+	name := a.Name
+	switch a.Kind {
+	case argUser, argRole:
+		name = "@" + a.Name
+	case argChannel:
+		name = "#" + a.Name
+	case argChoice:
+		name = strings.Join(a.Choices, "|")
+	}
+
+	if a.Optional {
+		return "[" + name + "]"
+	}
+	return "<" + name + ">"
+}
+
+// command is one entry of the command registry
+type command struct {
+	Name        string
+	Aliases     []string
+	Category    string
+	Tier        tier
+	Args        []argSpec
+	Description string // one line, also used for slash commands (max 100 chars)
+	Example     string // without prefix, e.g. "tempban @user 7d spamming"
+	Slash       bool   // also register as a slash command
+	Confirm     bool   // ask the moderator to confirm first if CONFIRM_ACTIONS is on
+	Handler     func(b *Bot, ctx *commandContext, args commandArgs)
+}
+
+// usage returns e.g. "!ban <@user> [reason]"
+func (c *command) usage() string {
+	parts := []string{config.Cfg.Prefix + c.Name}
+	for _, a := range c.Args {
+		parts = append(parts, a.usage())
+	}
+	return strings.Join(parts, " ")
+}
+
+// usageText is the reply for a missing or malformed argument
+func (c *command) usageText() string {
+	text := "Usage: `" + c.usage() + "`"
+	if c.Example != "" {
+		text += "\n\n**Example:** `" + config.Cfg.Prefix + c.Example + "`"
+	}
+	return text
+}
+
+// commandArgs holds the parsed arguments of one invocation by name
+type commandArgs map[string]any
+
+func (a commandArgs) Has(name string) bool {
+	_, ok := a[name]
+	return ok
+}
+
+func (a commandArgs) String(name string) string {
+	v, _ := a[name].(string)
+	return v
+}
+
+// User returns the user ID of a user argument
+func (a commandArgs) User(name string) string {
+	return a.String(name)
+}
+
+func (a commandArgs) Int(name string) int {
+	v, _ := a[name].(int)
+	return v
+}
+
+func (a commandArgs) Duration(name string) time.Duration {
+	v, _ := a[name].(time.Duration)
+	return v
+}
+
+// commandRegistry resolves command names and aliases to commands
+type commandRegistry struct {
+	list   []*command
+	byName map[string]*command
+}
+
+func newCommandRegistry(commands []*command) *commandRegistry {
+	r := &commandRegistry{byName: make(map[string]*command)}
+	for _, c := range commands {
+		r.register(c)
+	}
+	return r
+}
+
+// register adds c; a duplicate name or alias is a programming error
+func (r *commandRegistry) register(c *command) {
+	for _, name := range append([]string{c.Name}, c.Aliases...) {
+		if _, exists := r.byName[name]; exists {
+			panic("duplicate command name: " + name)
+		}
+		r.byName[name] = c
+	}
+	r.list = append(r.list, c)
+}
+
+func (r *commandRegistry) lookup(name string) *command {
+	return r.byName[strings.ToLower(name)]
+}
+
+// allowed replies and returns false if the invoker's tier is below c's
+func allowed(ctx *commandContext, c *command) bool {
+	if c.Tier == tierEveryone || memberTier(ctx.Session, ctx.GuildID, ctx.Author.ID) >= c.Tier {
+		return true
+	}
+
+	log.Printf("Permission denied for user %s attempting to use %s", ctx.Author.Username, c.Name)
+	if c.Tier == tierStaff {
+		ctx.Reply("❌ You don't have permission to use this command. (Admin/Staff only)")
+	} else {
+		ctx.Reply("❌ You don't have permission to use this command.")
+	}
+	return false
+}
+
+// execute runs c with parsed arguments, asking for confirmation first when
+// c is destructive and confirmations are enabled
+func (b *Bot) execute(ctx *commandContext, c *command, args commandArgs) {
+	if c.Confirm && config.Cfg.ConfirmActions {
+		b.requestConfirmation(ctx, c, args)
+		return
+	}
+	c.Handler(b, ctx, args)
+}
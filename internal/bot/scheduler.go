@@ -0,0 +1,270 @@
+package bot
+
+import (
+	"discord-mod-bot/internal/config"
+	"discord-mod-bot/internal/store"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	expiryActionUnmute = "unmute"
+	expiryActionUnban  = "unban"
+
+	expiriesFile = "expiries.json"
+)
+
+// expiry is a timed moderation action that has to be reverted at ExpiresAt
+type expiry struct {
+	Action      string    `json:"action"`
+	GuildID     string    `json:"guild_id"`
+	UserID      string    `json:"user_id"`
+	ModeratorID string    `json:"moderator_id"`
+	CaseID      int       `json:"case_id,omitempty"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (e expiry) key() string {
+	return e.Action + ":" + e.GuildID + ":" + e.UserID
+}
+
+// scheduler keeps one timer per pending expiry and persists the pending set
+// to disk so expirations survive restarts
+type scheduler struct {
+	mu      sync.Mutex
+	path    string
+	pending map[string]expiry
+	timers  map[string]*time.Timer
+}
+
+func newScheduler(dataDir string) *scheduler {
+	return &scheduler{
+		path:    filepath.Join(dataDir, expiriesFile),
+		pending: make(map[string]expiry),
+		timers:  make(map[string]*time.Timer),
+	}
+}
+
+// load reads pending expirations from disk, replacing the in-memory set
+func (sc *scheduler) load() ([]expiry, error) {
+	data, err := os.ReadFile(sc.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", sc.path, err)
+	}
+
+	var list []expiry
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", sc.path, err)
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	for _, e := range list {
+		sc.pending[e.key()] = e
+	}
+	return list, nil
+}
+
+// save writes the pending set to disk. Caller must hold sc.mu.
+func (sc *scheduler) save() error {
+	list := make([]expiry, 0, len(sc.pending))
+	for _, e := range sc.pending {
+		list = append(list, e)
+	}
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(sc.path), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temp file first so a crash never leaves a truncated file
+	tmp := sc.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, sc.path)
+}
+
+// schedule registers e and arms its timer, replacing any previous timer for
+// the same action and user. fire is called once the expiry is due.
+func (sc *scheduler) schedule(e expiry, fire func(expiry)) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	key := e.key()
+	if t, ok := sc.timers[key]; ok {
+		t.Stop()
+	}
+
+	sc.pending[key] = e
+	sc.timers[key] = time.AfterFunc(time.Until(e.ExpiresAt), func() {
+		fire(e)
+	})
+
+	return sc.save()
+}
+
+// cancel stops and forgets the expiry for action/user, if any.
+// Returns true if something was pending.
+func (sc *scheduler) cancel(action, guildID, userID string) (bool, error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	key := expiry{Action: action, GuildID: guildID, UserID: userID}.key()
+	if t, ok := sc.timers[key]; ok {
+		t.Stop()
+		delete(sc.timers, key)
+	}
+
+	if _, ok := sc.pending[key]; !ok {
+		return false, nil
+	}
+	delete(sc.pending, key)
+	return true, sc.save()
+}
+
+// done forgets e after it fired, unless it was replaced in the meantime
+func (sc *scheduler) done(e expiry) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	key := e.key()
+	current, ok := sc.pending[key]
+	if !ok || !current.ExpiresAt.Equal(e.ExpiresAt) {
+		return nil
+	}
+	delete(sc.pending, key)
+	delete(sc.timers, key)
+	return sc.save()
+}
+
+// stop disarms every timer without touching the persisted set
+func (sc *scheduler) stop() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	for key, t := range sc.timers {
+		t.Stop()
+		delete(sc.timers, key)
+	}
+}
+
+// scheduleExpiry persists e and arms its timer
+func (b *Bot) scheduleExpiry(e expiry) {
+	if err := b.scheduler.schedule(e, b.runExpiry); err != nil {
+		log.Printf("Scheduler: Error saving %s expiry for user %s: %v", e.Action, e.UserID, err)
+	}
+}
+
+// cancelExpiry drops a pending expiry, e.g. after a manual unmute
+func (b *Bot) cancelExpiry(action, guildID, userID string) {
+	if _, err := b.scheduler.cancel(action, guildID, userID); err != nil {
+		log.Printf("Scheduler: Error cancelling %s expiry for user %s: %v", action, userID, err)
+	}
+}
+
+// loadExpiries re-arms every persisted expiry. Ones that came due while the
+// bot was offline fire immediately.
+func (b *Bot) loadExpiries() {
+	list, err := b.scheduler.load()
+	if err != nil {
+		log.Printf("Scheduler: Error loading pending expiries: %v", err)
+		return
+	}
+
+	for _, e := range list {
+		if err := b.scheduler.schedule(e, b.runExpiry); err != nil {
+			log.Printf("Scheduler: Error re-arming %s expiry for user %s: %v", e.Action, e.UserID, err)
+		}
+	}
+
+	log.Printf("Scheduler: Loaded %d pending expiries", len(list))
+}
+
+// runExpiry reverts a timed action once it is due
+func (b *Bot) runExpiry(e expiry) {
+	s := b.Session
+
+	switch e.Action {
+	case expiryActionUnmute:
+		b.expireMute(s, e)
+	case expiryActionUnban:
+		b.expireBan(s, e)
+	default:
+		log.Printf("Scheduler: Unknown expiry action '%s' for user %s", e.Action, e.UserID)
+	}
+
+	if err := b.scheduler.done(e); err != nil {
+		log.Printf("Scheduler: Error saving after %s expiry for user %s: %v", e.Action, e.UserID, err)
+	}
+}
+
+// expireMute removes the mute role from a member whose mute ran out
+func (b *Bot) expireMute(s *discordgo.Session, e expiry) {
+	if config.Cfg.MuteRoleID == "" {
+		log.Printf("Scheduler: Mute role not configured, cannot unmute user %s", e.UserID)
+		return
+	}
+
+	err := s.GuildMemberRoleRemove(e.GuildID, e.UserID, config.Cfg.MuteRoleID)
+	if err != nil {
+		// 404 means the member left; nothing left to undo
+		log.Printf("Scheduler: Error removing mute role from user %s: %v", e.UserID, err)
+		return
+	}
+
+	log.Printf("Scheduler: Mute expired for user %s", e.UserID)
+
+	moderatorID := botUserID(s)
+	if moderatorID == "" {
+		moderatorID = e.ModeratorID
+	}
+
+	reason := fmt.Sprintf("Mute issued by <@%s> expired", e.ModeratorID)
+	if e.CaseID != 0 {
+		reason = fmt.Sprintf("Mute from case #%d issued by <@%s> expired", e.CaseID, e.ModeratorID)
+	}
+
+	c := b.recordCase(e.GuildID, store.ActionUnmute, moderatorID, e.UserID, reason, time.Time{})
+	b.logCase(s, c)
+}
+
+// expireBan lifts a temporary ban that ran out
+func (b *Bot) expireBan(s *discordgo.Session, e expiry) {
+	err := s.GuildBanDelete(e.GuildID, e.UserID)
+	if err != nil {
+		// 404 means someone already unbanned them manually
+		log.Printf("Scheduler: Error lifting temporary ban of user %s: %v", e.UserID, err)
+		return
+	}
+
+	log.Printf("Scheduler: Temporary ban expired for user %s", e.UserID)
+
+	moderatorID := botUserID(s)
+	if moderatorID == "" {
+		moderatorID = e.ModeratorID
+	}
+
+	reason := fmt.Sprintf("Temporary ban issued by <@%s> expired", e.ModeratorID)
+	if e.CaseID != 0 {
+		reason = fmt.Sprintf("Temporary ban from case #%d issued by <@%s> expired", e.CaseID, e.ModeratorID)
+	}
+
+	c := b.recordCase(e.GuildID, store.ActionUnban, moderatorID, e.UserID, reason, time.Time{})
+	b.logCase(s, c)
+}
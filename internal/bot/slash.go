@@ -0,0 +1,198 @@
+package bot
+
+import (
+	"discord-mod-bot/internal/config"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// slashOptionTypes maps argument kinds to application command option types.
+// Durations travel as strings and are parsed like prefix arguments.
+var slashOptionTypes = map[argKind]discordgo.ApplicationCommandOptionType{
+	argUser:     discordgo.ApplicationCommandOptionUser,
+	argRole:     discordgo.ApplicationCommandOptionRole,
+	argChannel:  discordgo.ApplicationCommandOptionChannel,
+	argWord:     discordgo.ApplicationCommandOptionString,
+	argInt:      discordgo.ApplicationCommandOptionInteger,
+	argDuration: discordgo.ApplicationCommandOptionString,
+	argRest:     discordgo.ApplicationCommandOptionString,
+}
+
+// slashCommand builds the application command for c. A leading argChoice
+// becomes one subcommand per choice taking the remaining arguments.
+func slashCommand(c *command) *discordgo.ApplicationCommand {
+	cmd := &discordgo.ApplicationCommand{
+		Name:        c.Name,
+		Description: c.Description,
+	}
+
+	if len(c.Args) > 0 && c.Args[0].Kind == argChoice {
+		for _, choice := range c.Args[0].Choices {
+			cmd.Options = append(cmd.Options, &discordgo.ApplicationCommandOption{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        choice,
+				Description: capitalize(choice) + " (" + c.Name + ")",
+				Options:     slashOptions(c.Args[1:]),
+			})
+		}
+		return cmd
+	}
+
+	cmd.Options = slashOptions(c.Args)
+	return cmd
+}
+
+func slashOptions(specs []argSpec) []*discordgo.ApplicationCommandOption {
+	var options []*discordgo.ApplicationCommandOption
+	for _, spec := range specs {
+		description := spec.Description
+		if description == "" {
+			description = capitalize(spec.Name)
+		}
+
+		opt := &discordgo.ApplicationCommandOption{
+			Type:        slashOptionTypes[spec.Kind],
+			Name:        spec.Name,
+			Description: description,
+			Required:    !spec.Optional,
+		}
+		if spec.Kind == argInt {
+			minValue := 1.0
+			opt.MinValue = &minValue
+		}
+		options = append(options, opt)
+	}
+	return options
+}
+
+// registerSlashCommands replaces the guild's application commands with the
+// registry's slash commands. Guild commands update instantly, unlike global ones.
+func (b *Bot) registerSlashCommands(s *discordgo.Session) {
+	if s.State == nil || s.State.User == nil || config.Cfg.GuildID == "" {
+		log.Println("Slash: Skipping command registration (no application or guild ID)")
+		return
+	}
+
+	var commands []*discordgo.ApplicationCommand
+	for _, c := range b.commands.list {
+		if c.Slash {
+			commands = append(commands, slashCommand(c))
+		}
+	}
+
+	registered, err := s.ApplicationCommandBulkOverwrite(s.State.User.ID, config.Cfg.GuildID, commands)
+	if err != nil {
+		log.Printf("Slash: Error registering commands: %v", err)
+		return
+	}
+
+	log.Printf("Slash: Registered %d commands", len(registered))
+}
+
+// parseSlashArgs maps interaction options onto the command's argument spec
+func (c *command) parseSlashArgs(ctx *commandContext, data discordgo.ApplicationCommandInteractionData) (commandArgs, error) {
+	args := make(commandArgs)
+	specs := c.Args
+	options := data.Options
+
+	if len(specs) > 0 && specs[0].Kind == argChoice {
+		if len(options) == 0 {
+			return nil, c.missing(specs[0])
+		}
+		args[specs[0].Name] = options[0].Name
+		specs = specs[1:]
+		options = options[0].Options
+	}
+
+	byName := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		byName[opt.Name] = opt
+	}
+
+	for _, spec := range specs {
+		opt, ok := byName[spec.Name]
+		if !ok {
+			if !spec.Optional {
+				return nil, c.missing(spec)
+			}
+			if spec.Default != "" {
+				args[spec.Name] = spec.Default
+			}
+			continue
+		}
+
+		switch spec.Kind {
+		case argUser, argRole, argChannel:
+			// The raw value is the ID, which also covers users outside the guild
+			id, _ := opt.Value.(string)
+			args[spec.Name] = id
+		case argInt:
+			args[spec.Name] = int(opt.IntValue())
+		default:
+			v, err := c.parseValue(ctx, spec, strings.TrimSpace(opt.StringValue()))
+			if err != nil {
+				return nil, err
+			}
+			args[spec.Name] = v
+		}
+	}
+
+	return args, nil
+}
+
+func (b *Bot) onInteractionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Commands are registered per guild, but stay safe if the bot is shared
+	if i.GuildID == "" || (config.Cfg.GuildID != "" && i.GuildID != config.Cfg.GuildID) {
+		return
+	}
+
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+	case discordgo.InteractionMessageComponent:
+		customID := i.MessageComponentData().CustomID
+		if strings.HasPrefix(customID, confirmButtonPrefix) || strings.HasPrefix(customID, cancelButtonPrefix) {
+			b.handleConfirmationButton(s, i)
+		}
+		return
+	default:
+		return
+	}
+
+	ctx := newInteractionContext(s, i)
+	if ctx.Author == nil {
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	log.Printf("Slash: Processing /%s from user %s (ID: %s)", data.Name, ctx.Author.Username, ctx.Author.ID)
+
+	// Acknowledge right away; moderation actions can take longer than the
+	// three seconds Discord allows for the initial response
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		log.Printf("Slash: Error acknowledging /%s: %v", data.Name, err)
+		return
+	}
+
+	cmd := b.commands.lookup(data.Name)
+	if cmd == nil || !cmd.Slash {
+		ctx.Reply("❌ Unknown command.")
+		return
+	}
+
+	if !allowed(ctx, cmd) {
+		return
+	}
+
+	args, err := cmd.parseSlashArgs(ctx, data)
+	if err != nil {
+		ctx.Reply(err.Error())
+		return
+	}
+
+	b.execute(ctx, cmd, args)
+}
@@ -0,0 +1,139 @@
+package bot
+
+import (
+	"discord-mod-bot/internal/config"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// spamLimits are the SPAM_* thresholds; a limit of 0 turns that check off
+type spamLimits struct {
+	Messages        int
+	MessageWindow   time.Duration
+	Duplicates      int
+	DuplicateWindow time.Duration
+}
+
+func currentSpamLimits() spamLimits {
+	return spamLimits{
+		Messages:        config.Cfg.SpamMessageLimit,
+		MessageWindow:   time.Duration(config.Cfg.SpamMessageWindow) * time.Second,
+		Duplicates:      config.Cfg.SpamDuplicateLimit,
+		DuplicateWindow: time.Duration(config.Cfg.SpamDuplicateWindow) * time.Second,
+	}
+}
+
+// spamTracker keeps each user's recent messages in a sliding window
+type spamTracker struct {
+	mu        sync.Mutex
+	history   map[string][]spamEntry // userID -> recent messages, oldest first
+	lastSweep time.Time
+}
+
+type spamEntry struct {
+	ref     messageRef
+	content string // normalized; empty for messages without text
+	at      time.Time
+}
+
+func newSpamTracker() *spamTracker {
+	return &spamTracker{history: make(map[string][]spamEntry)}
+}
+
+// normalizeSpamContent makes trivially different copies compare equal
+func normalizeSpamContent(content string) string {
+	return strings.Join(strings.Fields(strings.ToLower(content)), " ")
+}
+
+// record adds a message for userID and checks it against limits. On a trip
+// it returns the messages of the burst and a description, and clears the
+// user's history so one burst only triggers once.
+func (t *spamTracker) record(userID string, e spamEntry, limits spamLimits) ([]messageRef, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	keep := limits.MessageWindow
+	if limits.DuplicateWindow > keep {
+		keep = limits.DuplicateWindow
+	}
+
+	// Users who stopped posting would otherwise stay in the map forever
+	if e.at.Sub(t.lastSweep) > time.Minute {
+		for id, entries := range t.history {
+			if e.at.Sub(entries[len(entries)-1].at) > keep {
+				delete(t.history, id)
+			}
+		}
+		t.lastSweep = e.at
+	}
+
+	recent := t.history[userID][:0:0]
+	for _, old := range t.history[userID] {
+		if e.at.Sub(old.at) <= keep {
+			recent = append(recent, old)
+		}
+	}
+	recent = append(recent, e)
+
+	var burst []messageRef
+	var reason string
+
+	if limits.Messages > 0 {
+		var inWindow []messageRef
+		for _, old := range recent {
+			if e.at.Sub(old.at) <= limits.MessageWindow {
+				inWindow = append(inWindow, old.ref)
+			}
+		}
+		if len(inWindow) >= limits.Messages {
+			burst = inWindow
+			reason = fmt.Sprintf("Sent %d messages within %s", len(inWindow), formatDuration(limits.MessageWindow))
+		}
+	}
+
+	if burst == nil && limits.Duplicates > 0 && e.content != "" {
+		var copies []messageRef
+		for _, old := range recent {
+			if old.content == e.content && e.at.Sub(old.at) <= limits.DuplicateWindow {
+				copies = append(copies, old.ref)
+			}
+		}
+		if len(copies) >= limits.Duplicates {
+			burst = copies
+			reason = fmt.Sprintf("Sent the same message %d times within %s", len(copies), formatDuration(limits.DuplicateWindow))
+		}
+	}
+
+	if burst != nil {
+		delete(t.history, userID)
+		return burst, reason
+	}
+
+	t.history[userID] = recent
+	return nil, ""
+}
+
+// checkSpam is the automod check for message floods and repeated content
+func (b *Bot) checkSpam(s *discordgo.Session, m *discordgo.Message) *automodViolation {
+	entry := spamEntry{
+		ref:     messageRef{ChannelID: m.ChannelID, ID: m.ID},
+		content: normalizeSpamContent(m.Content),
+		at:      time.Now(),
+	}
+
+	burst, reason := b.spam.record(m.Author.ID, entry, currentSpamLimits())
+	if burst == nil {
+		return nil
+	}
+
+	return &automodViolation{
+		Rule:     "Spam",
+		Reason:   reason,
+		Action:   b.spamAction,
+		Messages: burst,
+	}
+}
@@ -0,0 +1,182 @@
+package bot
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNormalizeSpamContent(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Hello World", "hello world"},
+		{"  hello \n\t world  ", "hello world"},
+		{"", ""},
+		{"   ", ""},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeSpamContent(tt.input); got != tt.want {
+			t.Errorf("normalizeSpamContent(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+// spamMessage is one message sent at offset from the start of a test
+type spamMessage struct {
+	user    string
+	content string
+	offset  time.Duration
+}
+
+func TestSpamTrackerRecord(t *testing.T) {
+	limits := spamLimits{
+		Messages:        4,
+		MessageWindow:   5 * time.Second,
+		Duplicates:      3,
+		DuplicateWindow: 30 * time.Second,
+	}
+
+	tests := []struct {
+		name     string
+		limits   spamLimits
+		messages []spamMessage
+		tripAt   int // index of the message that trips, -1 for none
+		burst    int
+	}{
+		{
+			name:   "flood",
+			limits: limits,
+			messages: []spamMessage{
+				{"u", "a", 0}, {"u", "b", time.Second}, {"u", "c", 2 * time.Second}, {"u", "d", 3 * time.Second},
+			},
+			tripAt: 3,
+			burst:  4,
+		},
+		{
+			name:   "flood spread past the window",
+			limits: limits,
+			messages: []spamMessage{
+				{"u", "a", 0}, {"u", "b", 2 * time.Second}, {"u", "c", 4 * time.Second},
+				{"u", "d", 6 * time.Second}, {"u", "e", 8 * time.Second},
+			},
+			tripAt: -1,
+		},
+		{
+			name:   "duplicates",
+			limits: limits,
+			messages: []spamMessage{
+				{"u", "Free Nitro", 0}, {"u", "free  nitro", 10 * time.Second}, {"u", "FREE NITRO", 20 * time.Second},
+			},
+			tripAt: 2,
+			burst:  3,
+		},
+		{
+			name:   "duplicates outside the window",
+			limits: limits,
+			messages: []spamMessage{
+				{"u", "hi", 0}, {"u", "hi", 20 * time.Second}, {"u", "hi", 40 * time.Second},
+			},
+			tripAt: -1,
+		},
+		{
+			name:   "duplicates only count identical text",
+			limits: limits,
+			messages: []spamMessage{
+				{"u", "hi", 0}, {"u", "hello", 10 * time.Second}, {"u", "hi", 20 * time.Second},
+			},
+			tripAt: -1,
+		},
+		{
+			name:   "messages without text aren't duplicates",
+			limits: limits,
+			messages: []spamMessage{
+				{"u", "", 0}, {"u", "", 10 * time.Second}, {"u", "", 20 * time.Second},
+			},
+			tripAt: -1,
+		},
+		{
+			name:   "users are counted apart",
+			limits: limits,
+			messages: []spamMessage{
+				{"u", "a", 0}, {"v", "b", time.Second}, {"u", "c", 2 * time.Second}, {"v", "d", 3 * time.Second},
+			},
+			tripAt: -1,
+		},
+		{
+			name:   "disabled limits",
+			limits: spamLimits{MessageWindow: 5 * time.Second, DuplicateWindow: 30 * time.Second},
+			messages: []spamMessage{
+				{"u", "a", 0}, {"u", "a", 0}, {"u", "a", 0}, {"u", "a", 0}, {"u", "a", 0},
+			},
+			tripAt: -1,
+		},
+	}
+
+	start := time.Now()
+	for _, tt := range tests {
+		tracker := newSpamTracker()
+		tripped := -1
+		for i, m := range tt.messages {
+			e := spamEntry{
+				ref:     messageRef{ChannelID: "c", ID: fmt.Sprint(i)},
+				content: normalizeSpamContent(m.content),
+				at:      start.Add(m.offset),
+			}
+			burst, reason := tracker.record(m.user, e, tt.limits)
+			if burst == nil {
+				continue
+			}
+			if tripped >= 0 {
+				t.Errorf("%s: tripped again at message %d", tt.name, i)
+			}
+			tripped = i
+			if len(burst) != tt.burst {
+				t.Errorf("%s: burst of %d messages, want %d", tt.name, len(burst), tt.burst)
+			}
+			if reason == "" {
+				t.Errorf("%s: no reason", tt.name)
+			}
+		}
+		if tripped != tt.tripAt {
+			t.Errorf("%s: tripped at message %d, want %d", tt.name, tripped, tt.tripAt)
+		}
+	}
+}
+
+func TestSpamTrackerResetsAfterTrip(t *testing.T) {
+	limits := spamLimits{Messages: 3, MessageWindow: 5 * time.Second}
+	tracker := newSpamTracker()
+	start := time.Now()
+
+	trips := 0
+	for i := 0; i < 5; i++ {
+		e := spamEntry{ref: messageRef{ID: fmt.Sprint(i)}, at: start.Add(time.Duration(i) * 100 * time.Millisecond)}
+		if burst, _ := tracker.record("u", e, limits); burst != nil {
+			trips++
+		}
+	}
+
+	// Messages 0-2 trip, then 3-4 start a new count
+	if trips != 1 {
+		t.Errorf("%d trips, want 1", trips)
+	}
+}
+
+func TestSpamTrackerSweep(t *testing.T) {
+	limits := spamLimits{Messages: 10, MessageWindow: 5 * time.Second}
+	tracker := newSpamTracker()
+	start := time.Now()
+
+	tracker.record("gone", spamEntry{at: start}, limits)
+	tracker.record("active", spamEntry{at: start.Add(2 * time.Minute)}, limits)
+
+	if _, ok := tracker.history["gone"]; ok {
+		t.Error("history of an inactive user was kept")
+	}
+	if _, ok := tracker.history["active"]; !ok {
+		t.Error("history of an active user was dropped")
+	}
+}
@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"discord-mod-bot/internal/config"
+	"discord-mod-bot/internal/store"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// stickyRoles returns the roles from roles that should survive a rejoin
+func stickyRoles(roles []string) []string {
+	sticky := make(map[string]bool, len(config.Cfg.StickyRoleIDs)+1)
+	for _, roleID := range config.Cfg.StickyRoleIDs {
+		sticky[roleID] = true
+	}
+	if config.Cfg.MuteRoleID != "" {
+		sticky[config.Cfg.MuteRoleID] = true
+	}
+	if config.Cfg.QuarantineRoleID != "" {
+		sticky[config.Cfg.QuarantineRoleID] = true
+	}
+
+	var kept []string
+	for _, roleID := range roles {
+		if sticky[roleID] {
+			kept = append(kept, roleID)
+		}
+	}
+	return kept
+}
+
+// muteActive reports whether the user's latest mute case is still running.
+// A timed mute that ran out while the member was away has no unmute case,
+// because the role couldn't be removed from someone not in the guild.
+func (b *Bot) muteActive(userID string) bool {
+	cases, err := b.store.CasesForUser(userID)
+	if err != nil {
+		log.Printf("Sticky: Error loading cases for user %s: %v", userID, err)
+		return true // Keep the mute rather than lift it by accident
+	}
+
+	for i := len(cases) - 1; i >= 0; i-- {
+		switch cases[i].Action {
+		case store.ActionUnmute:
+			return false
+		case store.ActionMute:
+			return cases[i].ExpiresAt.IsZero() || cases[i].ExpiresAt.After(time.Now())
+		}
+	}
+
+	// Muted outside the bot before cases were recorded
+	return true
+}
+
+// restoreStickyRoles gives a rejoining member the sticky roles they had when
+// they last left
+func (b *Bot) restoreStickyRoles(s *discordgo.Session, guildID, userID string) {
+	last, err := b.store.LastDeparture(userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return
+	}
+	if err != nil {
+		log.Printf("Sticky: Error loading departures for user %s: %v", userID, err)
+		return
+	}
+
+	// Roles removed from the sticky list since then are left alone
+	roles := stickyRoles(last.Roles)
+	if len(roles) == 0 {
+		return
+	}
+
+	var restored, failed []string
+	for _, roleID := range roles {
+		if roleID == config.Cfg.MuteRoleID && !b.muteActive(userID) {
+			log.Printf("Sticky: Mute of user %s ran out while they were away, not restoring it", userID)
+			continue
+		}
+
+		if err := s.GuildMemberRoleAdd(guildID, userID, roleID); err != nil {
+			log.Printf("Sticky: Error restoring role %s to user %s: %v", roleID, userID, err)
+			failed = append(failed, fmt.Sprintf("<@&%s>", roleID))
+			continue
+		}
+		restored = append(restored, fmt.Sprintf("<@&%s>", roleID))
+	}
+
+	if len(restored) == 0 && len(failed) == 0 {
+		return
+	}
+
+	log.Printf("Sticky: Restored %d role(s) to user %s", len(restored), userID)
+
+	details := "Restored: " + strings.Join(restored, " ")
+	if len(restored) == 0 {
+		details = "Restored: none"
+	}
+	if len(failed) > 0 {
+		details += "\n⚠️ Failed: " + strings.Join(failed, " ") + "\nCheck the bot's role is above these roles."
+	}
+	b.logAction(s, logStickyRestored, botUserID(s), userID, details)
+}
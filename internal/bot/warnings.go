@@ -0,0 +1,286 @@
+package bot
+
+import (
+	"discord-mod-bot/internal/store"
+	"discord-mod-bot/internal/utils"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// escalationRule turns a number of active warnings into a harsher action
+type escalationRule struct {
+	Count    int
+	Window   time.Duration // 0 counts every active warning
+	Action   string        // store.ActionMute, store.ActionKick or store.ActionBan
+	Duration time.Duration // mute length, 0 for permanent
+}
+
+func (r escalationRule) String() string {
+	desc := fmt.Sprintf("%d warnings", r.Count)
+	if r.Window > 0 {
+		desc += " within " + formatDuration(r.Window)
+	}
+	return desc
+}
+
+// parseEscalationRules parses WARN_ESCALATION, e.g. "3/7d:mute:1h,5:kick".
+// Rules are returned strongest (highest count) first.
+func parseEscalationRules(raw string) ([]escalationRule, error) {
+	var rules []escalationRule
+
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		fields := strings.Split(part, ":")
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("invalid escalation rule %q", part)
+		}
+
+		var rule escalationRule
+
+		countStr, windowStr, hasWindow := strings.Cut(fields[0], "/")
+		count, err := strconv.Atoi(countStr)
+		if err != nil || count < 1 {
+			return nil, fmt.Errorf("invalid warning count in rule %q", part)
+		}
+		rule.Count = count
+
+		if hasWindow {
+			window, err := parseDuration(windowStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid window in rule %q: %w", part, err)
+			}
+			rule.Window = window
+		}
+
+		switch action := strings.ToLower(fields[1]); action {
+		case store.ActionMute, store.ActionKick, store.ActionBan:
+			rule.Action = action
+		default:
+			return nil, fmt.Errorf("invalid action %q in rule %q", fields[1], part)
+		}
+
+		if len(fields) == 3 {
+			if rule.Action != store.ActionMute {
+				return nil, fmt.Errorf("duration is only supported for mute in rule %q", part)
+			}
+			duration, err := parseDuration(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid duration in rule %q: %w", part, err)
+			}
+			rule.Duration = duration
+		}
+
+		rules = append(rules, rule)
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Count > rules[j].Count
+	})
+
+	return rules, nil
+}
+
+// warnMember records a warning against userID and applies any escalation
+// rule it triggers. The returned string describes the escalation, if any.
+func (b *Bot) warnMember(s *discordgo.Session, guildID, moderatorID, userID, reason string) (*store.Warning, *store.Case, string, error) {
+	c := b.recordCase(guildID, store.ActionWarn, moderatorID, userID, reason, time.Time{})
+
+	w := &store.Warning{
+		GuildID:     guildID,
+		UserID:      userID,
+		ModeratorID: moderatorID,
+		Reason:      reason,
+		CaseID:      c.ID,
+	}
+	if err := b.store.CreateWarning(w); err != nil {
+		// A warn case without its warning would throw off the counts
+		if c.ID != 0 {
+			if delErr := b.store.DeleteCase(c.ID); delErr != nil {
+				log.Printf("Cases: Error removing case #%d of failed warning: %v", c.ID, delErr)
+			}
+		}
+		return nil, nil, "", err
+	}
+
+	b.logCase(s, c)
+
+	escalation := b.escalateWarnings(s, guildID, userID)
+	return w, c, escalation, nil
+}
+
+// escalateWarnings checks the user's active warnings against the escalation
+// rules and applies the strongest rule whose count was just reached
+func (b *Bot) escalateWarnings(s *discordgo.Session, guildID, userID string) string {
+	if len(b.escalationRules) == 0 {
+		return ""
+	}
+
+	warnings, err := b.store.WarningsForUser(userID)
+	if err != nil {
+		log.Printf("Warnings: Error loading warnings for user %s: %v", userID, err)
+		return ""
+	}
+
+	now := time.Now()
+	for _, rule := range b.escalationRules {
+		count := 0
+		for _, w := range warnings {
+			if rule.Window == 0 || now.Sub(w.CreatedAt) <= rule.Window {
+				count++
+			}
+		}
+
+		// Fire only when the threshold is reached, not on every later warning
+		if count != rule.Count {
+			continue
+		}
+
+		reason := "Automatic escalation: " + rule.String()
+		moderatorID := botUserID(s)
+
+		if err := utils.CheckHierarchy(s, guildID, moderatorID, userID); err != nil {
+			log.Printf("Warnings: Skipping %s escalation for user %s: %v", rule.Action, userID, err)
+			return fmt.Sprintf("⚠️ Reached %s but escalation was skipped: %v", rule, err)
+		}
+
+		var result string
+		switch rule.Action {
+		case store.ActionMute:
+			_, err = b.muteMember(s, guildID, moderatorID, userID, reason, rule.Duration)
+			result = "muted"
+			if rule.Duration > 0 {
+				result += " for " + formatDuration(rule.Duration)
+			}
+		case store.ActionKick:
+			_, err = b.kickMember(s, guildID, moderatorID, userID, reason)
+			result = "kicked"
+		case store.ActionBan:
+			_, err = b.banMember(s, guildID, moderatorID, userID, reason, 0)
+			result = "banned"
+		}
+
+		if err != nil {
+			log.Printf("Warnings: Error applying %s escalation to user %s: %v", rule.Action, userID, err)
+			return fmt.Sprintf("⚠️ Escalation (%s) failed: %v", rule, err)
+		}
+
+		log.Printf("Warnings: User %s %s after reaching %s", userID, result, rule)
+		return fmt.Sprintf("🔺 Reached %s and was automatically %s.", rule, result)
+	}
+
+	return ""
+}
+
+func (b *Bot) handleWarn(ctx *commandContext, args commandArgs) {
+	s := ctx.Session
+	userID := args.User("user")
+	reason := args.String("reason")
+
+	// Refuse targets at or above the moderator or the bot
+	if !checkHierarchy(ctx, userID) {
+		return
+	}
+
+	// Check moderation quota
+	quota, ok := checkQuota(ctx, utils.ActionWarn)
+	if !ok {
+		return
+	}
+
+	w, c, escalation, err := b.warnMember(s, ctx.GuildID, ctx.Author.ID, userID, reason)
+	if err != nil {
+		quota.Cancel()
+		log.Printf("Error warning user: %v", err)
+		ctx.Reply("❌ Failed to warn user.")
+		return
+	}
+
+	count := 0
+	if warnings, err := b.store.WarningsForUser(userID); err == nil {
+		count = len(warnings)
+	}
+
+	response := fmt.Sprintf("⚠️ User <@%s> has been warned (Warning #%d). Reason: %s%s\nActive warnings: **%d**",
+		userID, w.ID, reason, caseSuffix(c), count)
+	if escalation != "" {
+		response += "\n" + escalation
+	}
+	ctx.Reply(response)
+}
+
+func (b *Bot) handleWarnings(ctx *commandContext, args commandArgs) {
+	s := ctx.Session
+
+	// Anyone may view their own warnings; viewing others needs a mod role
+	userID := ctx.Author.ID
+	if args.Has("user") {
+		userID = args.User("user")
+	}
+
+	if userID != ctx.Author.ID && !hasAnyModRole(s, ctx.GuildID, ctx.Author.ID) {
+		ctx.Reply("❌ You don't have permission to view other users' warnings.")
+		return
+	}
+
+	warnings, err := b.store.WarningsForUser(userID)
+	if err != nil {
+		log.Printf("Warnings: Error loading warnings for user %s: %v", userID, err)
+		ctx.Reply("❌ Failed to load warnings.")
+		return
+	}
+
+	if len(warnings) == 0 {
+		ctx.Reply(fmt.Sprintf("✅ <@%s> has no active warnings.", userID))
+		return
+	}
+
+	var lines []string
+	for i := len(warnings) - 1; i >= 0; i-- {
+		w := warnings[i]
+		lines = append(lines, fmt.Sprintf("`#%d` <t:%d:d> by <@%s>\n└ %s", w.ID, w.CreatedAt.Unix(), w.ModeratorID, w.Reason))
+	}
+
+	// Embed descriptions are capped at 4096 characters
+	description := fmt.Sprintf("<@%s> has **%d** active warning(s)\n\n%s", userID, len(warnings), strings.Join(lines, "\n"))
+	if r := []rune(description); len(r) > 4000 {
+		description = string(r[:4000]) + "\n…"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "⚠️ Warnings",
+		Description: description,
+		Color:       0xFEE75C, // Yellow
+	}
+	ctx.ReplyEmbed(embed)
+}
+
+func (b *Bot) handleDelWarn(ctx *commandContext, args commandArgs) {
+	s := ctx.Session
+	id := args.Int("number")
+
+	w, err := b.store.DeleteWarning(id)
+	if errors.Is(err, store.ErrNotFound) {
+		ctx.Reply(fmt.Sprintf("❌ Warning #%d not found.", id))
+		return
+	}
+	if err != nil {
+		log.Printf("Warnings: Error deleting warning #%d: %v", id, err)
+		ctx.Reply("❌ Failed to remove warning.")
+		return
+	}
+
+	ctx.Reply(fmt.Sprintf("✅ Removed warning #%d from <@%s>.", w.ID, w.UserID))
+
+	b.logAction(s, logWarningRemoved, ctx.Author.ID, w.UserID, fmt.Sprintf("Warning #%d: %s", w.ID, w.Reason))
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -16,6 +17,7 @@ type Config struct {
 	StaffRoleID       string
 	Prefix            string
 	MuteRoleID        string
+	MuteMode          string
 	LogChannelID      string
 	AutoNickChannelID string
 	VanityRoleID      string
@@ -23,6 +25,71 @@ type Config struct {
 	VanityRoleName    string
 	VanityCooldown    int
 	VanityEnabled     bool
+	DataDir           string
+	WarnEscalation    string
+	ModQuotas         string
+	AntiNukeEnabled   bool
+	AntiNukeThreshold int
+	AntiNukeWindow    int
+	AntiNukeOwnerIDs  []string
+	ConfirmActions    bool
+	ConfirmTimeout    int
+
+	// Per-event log channels; empty ones fall back to LogChannelID
+	ModLogChannelID    string
+	RoleLogChannelID   string
+	NickLogChannelID   string
+	VanityLogChannelID string
+
+	// Deleted/edited message logs; the cache bounds how many messages are kept
+	MessageLogChannelID string
+	MessageCacheSize    int
+	MessageCachePersist bool
+
+	// Join/leave logs; accounts younger than NewAccountDays are flagged
+	MemberLogChannelID string
+	NewAccountDays     int
+
+	// Roles given back to members who leave and rejoin; the mute role always is
+	StickyRoleIDs []string
+
+	// Automod; windows are in seconds and a limit of 0 turns that check off
+	AutomodEnabled      bool
+	AutomodLogChannelID string
+	SpamMessageLimit    int
+	SpamMessageWindow   int
+	SpamDuplicateLimit  int
+	SpamDuplicateWindow int
+	SpamAction          string
+	SpamActionDuration  string
+
+	// Blocklist hits are always deleted; the action applies on top
+	FilterAction         string
+	FilterActionDuration string
+
+	// Link filter; LinkDomainRules is parsed by the bot
+	LinkFilterEnabled   bool
+	LinkBlockInvites    bool
+	LinkAllowedGuildIDs []string
+	LinkDomainRules     string
+	LinkExemptRoleIDs   []string
+	LinkAction          string
+	LinkActionDuration  string
+
+	// Phishing links; the domain list file is re-read when it changes
+	PhishingDomainsFile    string
+	PhishingAction         string
+	PhishingActionDuration string
+	QuarantineRoleID       string
+
+	// Mass mentions; MentionExemptRoles is parsed by the bot
+	MentionMessageLimit   int
+	MentionWindowLimit    int
+	MentionWindow         int
+	MentionBlockEveryone  bool
+	MentionExemptRoles    string
+	MentionAction         string
+	MentionActionDuration string
 }
 
 var Cfg *Config
@@ -41,6 +108,7 @@ func Load() error {
 		StaffRoleID:       getEnv("STAFF_ROLE_ID", ""),
 		Prefix:            getEnv("PREFIX", "!"),
 		MuteRoleID:        getEnv("MUTE_ROLE_ID", ""),
+		MuteMode:          strings.ToLower(getEnv("MUTE_MODE", "role")),
 		LogChannelID:      getEnv("DISCORD_LOG_CHANNEL_ID", ""),
 		AutoNickChannelID: getEnv("AUTO_NICK_CHANNEL_ID", ""),
 		VanityRoleID:      getEnv("VANITY_ROLE_ID", ""),
@@ -48,6 +116,62 @@ func Load() error {
 		VanityRoleName:    getEnv("VANITY_ROLE_NAME", ""),
 		VanityCooldown:    getEnvAsInt("VANITY_COOLDOWN", 0),
 		VanityEnabled:     getEnvAsBool("VANITY_AUTO_ENABLED", false),
+		DataDir:           getEnv("DATA_DIR", "data"),
+		WarnEscalation:    getEnv("WARN_ESCALATION", ""),
+		ModQuotas:         getEnv("MOD_QUOTAS", ""),
+		AntiNukeEnabled:   getEnvAsBool("ANTINUKE_ENABLED", false),
+		AntiNukeThreshold: getEnvAsInt("ANTINUKE_THRESHOLD", 5),
+		AntiNukeWindow:    getEnvAsInt("ANTINUKE_WINDOW", 60),
+		AntiNukeOwnerIDs:  getEnvAsList("ANTINUKE_OWNER_IDS"),
+		ConfirmActions:    getEnvAsBool("CONFIRM_ACTIONS", false),
+		ConfirmTimeout:    getEnvAsInt("CONFIRM_TIMEOUT", 30),
+
+		ModLogChannelID:    getEnv("MOD_LOG_CHANNEL_ID", ""),
+		RoleLogChannelID:   getEnv("ROLE_LOG_CHANNEL_ID", ""),
+		NickLogChannelID:   getEnv("NICKNAME_LOG_CHANNEL_ID", ""),
+		VanityLogChannelID: getEnv("VANITY_LOG_CHANNEL_ID", ""),
+
+		MessageLogChannelID: getEnv("MESSAGE_LOG_CHANNEL_ID", ""),
+		MessageCacheSize:    getEnvAsInt("MESSAGE_CACHE_SIZE", 5000),
+		MessageCachePersist: getEnvAsBool("MESSAGE_CACHE_PERSIST", false),
+
+		MemberLogChannelID: getEnv("MEMBER_LOG_CHANNEL_ID", ""),
+		NewAccountDays:     getEnvAsInt("NEW_ACCOUNT_DAYS", 7),
+
+		StickyRoleIDs: getEnvAsList("STICKY_ROLE_IDS"),
+
+		AutomodEnabled:      getEnvAsBool("AUTOMOD_ENABLED", false),
+		AutomodLogChannelID: getEnv("AUTOMOD_LOG_CHANNEL_ID", ""),
+		SpamMessageLimit:    getEnvAsInt("SPAM_MESSAGE_LIMIT", 6),
+		SpamMessageWindow:   getEnvAsInt("SPAM_MESSAGE_WINDOW", 5),
+		SpamDuplicateLimit:  getEnvAsInt("SPAM_DUPLICATE_LIMIT", 3),
+		SpamDuplicateWindow: getEnvAsInt("SPAM_DUPLICATE_WINDOW", 30),
+		SpamAction:          strings.ToLower(getEnv("SPAM_ACTION", "timeout")),
+		SpamActionDuration:  getEnv("SPAM_ACTION_DURATION", "10m"),
+
+		FilterAction:         strings.ToLower(getEnv("FILTER_ACTION", "delete")),
+		FilterActionDuration: getEnv("FILTER_ACTION_DURATION", ""),
+
+		LinkFilterEnabled:   getEnvAsBool("LINK_FILTER_ENABLED", false),
+		LinkBlockInvites:    getEnvAsBool("LINK_BLOCK_INVITES", true),
+		LinkAllowedGuildIDs: getEnvAsList("LINK_ALLOWED_GUILD_IDS"),
+		LinkDomainRules:     getEnv("LINK_DOMAIN_RULES", ""),
+		LinkExemptRoleIDs:   getEnvAsList("LINK_EXEMPT_ROLE_IDS"),
+		LinkAction:          strings.ToLower(getEnv("LINK_ACTION", "delete")),
+		LinkActionDuration:  getEnv("LINK_ACTION_DURATION", ""),
+
+		PhishingDomainsFile:    getEnv("PHISHING_DOMAINS_FILE", ""),
+		PhishingAction:         strings.ToLower(getEnv("PHISHING_ACTION", "quarantine")),
+		PhishingActionDuration: getEnv("PHISHING_ACTION_DURATION", ""),
+		QuarantineRoleID:       getEnv("QUARANTINE_ROLE_ID", ""),
+
+		MentionMessageLimit:   getEnvAsInt("MENTION_MESSAGE_LIMIT", 8),
+		MentionWindowLimit:    getEnvAsInt("MENTION_WINDOW_LIMIT", 15),
+		MentionWindow:         getEnvAsInt("MENTION_WINDOW", 60),
+		MentionBlockEveryone:  getEnvAsBool("MENTION_BLOCK_EVERYONE", true),
+		MentionExemptRoles:    getEnv("MENTION_EXEMPT_ROLES", "admin,staff,mod"),
+		MentionAction:         strings.ToLower(getEnv("MENTION_ACTION", "timeout")),
+		MentionActionDuration: getEnv("MENTION_ACTION_DURATION", "1h"),
 	}
 
 	if Cfg.BotToken == "" {
@@ -76,6 +200,16 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func getEnvAsBool(key string, defaultValue bool) bool {
 	valueStr := os.Getenv(key)
 	if valueStr == "" {
@@ -0,0 +1,451 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// fileData is the on-disk layout of a FileStore
+type fileData struct {
+	NextCaseID    int        `json:"next_case_id"`
+	Cases         []*Case    `json:"cases"`
+	NextWarningID int        `json:"next_warning_id"`
+	Warnings      []*Warning `json:"warnings"`
+
+	ModActions []*ModAction `json:"mod_action_log"`
+	Departures []*Departure `json:"departures"`
+
+	NextFilterRuleID int           `json:"next_filter_rule_id"`
+	FilterRules      []*FilterRule `json:"filter_rules"`
+}
+
+// FileStore is a Store backed by a single JSON file. Every write rewrites
+// the file, which is fine for the volume a single guild produces.
+type FileStore struct {
+	mu   sync.RWMutex
+	path string
+	data fileData
+}
+
+// Open loads the store at path, creating it on first save if missing
+func Open(path string) (*FileStore, error) {
+	fs := &FileStore{
+		path: path,
+		data: fileData{NextCaseID: 1, NextWarningID: 1, NextFilterRuleID: 1},
+	}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(raw, &fs.data); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	if fs.data.NextCaseID < 1 {
+		fs.data.NextCaseID = 1
+	}
+	if fs.data.NextWarningID < 1 {
+		fs.data.NextWarningID = 1
+	}
+	if fs.data.NextFilterRuleID < 1 {
+		fs.data.NextFilterRuleID = 1
+	}
+	fs.data.Departures = mergeDepartures(fs.data.Departures)
+
+	return fs, nil
+}
+
+// save writes the store to disk. Caller must hold fs.mu for writing.
+func (fs *FileStore) save() error {
+	raw, err := json.MarshalIndent(&fs.data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fs.path), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temp file first so a crash never leaves a truncated file
+	tmp := fs.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fs.path)
+}
+
+func (fs *FileStore) CreateCase(c *Case) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	c.ID = fs.data.NextCaseID
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now()
+	}
+
+	stored := *c
+	fs.data.Cases = append(fs.data.Cases, &stored)
+	fs.data.NextCaseID++
+
+	if err := fs.save(); err != nil {
+		// Roll back so memory and disk stay in sync
+		fs.data.Cases = fs.data.Cases[:len(fs.data.Cases)-1]
+		fs.data.NextCaseID--
+		return err
+	}
+	return nil
+}
+
+func (fs *FileStore) Case(id int) (*Case, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	for _, c := range fs.data.Cases {
+		if c.ID == id {
+			found := *c
+			return &found, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (fs *FileStore) CasesForUser(targetID string) ([]*Case, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	var cases []*Case
+	for _, c := range fs.data.Cases {
+		if c.TargetID == targetID {
+			found := *c
+			cases = append(cases, &found)
+		}
+	}
+	return cases, nil
+}
+
+func (fs *FileStore) UpdateCase(c *Case) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for i, existing := range fs.data.Cases {
+		if existing.ID == c.ID {
+			updated := *c
+			fs.data.Cases[i] = &updated
+			if err := fs.save(); err != nil {
+				fs.data.Cases[i] = existing
+				return err
+			}
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (fs *FileStore) DeleteCase(id int) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for i, existing := range fs.data.Cases {
+		if existing.ID != id {
+			continue
+		}
+
+		previous := fs.data.Cases
+		previousNext := fs.data.NextCaseID
+		remaining := make([]*Case, 0, len(previous)-1)
+		remaining = append(remaining, previous[:i]...)
+		remaining = append(remaining, previous[i+1:]...)
+		fs.data.Cases = remaining
+
+		// Reuse the number if no case was recorded after it
+		if id == fs.data.NextCaseID-1 {
+			fs.data.NextCaseID--
+		}
+
+		if err := fs.save(); err != nil {
+			fs.data.Cases = previous
+			fs.data.NextCaseID = previousNext
+			return err
+		}
+		return nil
+	}
+	return ErrNotFound
+}
+
+func (fs *FileStore) CreateWarning(w *Warning) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	w.ID = fs.data.NextWarningID
+	if w.CreatedAt.IsZero() {
+		w.CreatedAt = time.Now()
+	}
+
+	stored := *w
+	fs.data.Warnings = append(fs.data.Warnings, &stored)
+	fs.data.NextWarningID++
+
+	if err := fs.save(); err != nil {
+		fs.data.Warnings = fs.data.Warnings[:len(fs.data.Warnings)-1]
+		fs.data.NextWarningID--
+		return err
+	}
+	return nil
+}
+
+func (fs *FileStore) WarningsForUser(userID string) ([]*Warning, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	var warnings []*Warning
+	for _, w := range fs.data.Warnings {
+		if w.UserID == userID {
+			found := *w
+			warnings = append(warnings, &found)
+		}
+	}
+	return warnings, nil
+}
+
+func (fs *FileStore) DeleteWarning(id int) (*Warning, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for i, w := range fs.data.Warnings {
+		if w.ID != id {
+			continue
+		}
+
+		previous := fs.data.Warnings
+		remaining := make([]*Warning, 0, len(previous)-1)
+		remaining = append(remaining, previous[:i]...)
+		remaining = append(remaining, previous[i+1:]...)
+		fs.data.Warnings = remaining
+
+		if err := fs.save(); err != nil {
+			fs.data.Warnings = previous
+			return nil, err
+		}
+
+		deleted := *w
+		return &deleted, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (fs *FileStore) ModActionsSince(userID, action string, since time.Time) ([]time.Time, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	var times []time.Time
+	for _, ma := range fs.data.ModActions {
+		if ma.UserID == userID && ma.Action == action && !ma.At.Before(since) {
+			times = append(times, ma.At)
+		}
+	}
+	return times, nil
+}
+
+func (fs *FileStore) RecordModAction(userID, action string, at time.Time) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.data.ModActions = append(fs.data.ModActions, &ModAction{
+		UserID: userID,
+		Action: action,
+		At:     at,
+	})
+
+	if err := fs.save(); err != nil {
+		fs.data.ModActions = fs.data.ModActions[:len(fs.data.ModActions)-1]
+		return err
+	}
+	return nil
+}
+
+func (fs *FileStore) RemoveModAction(userID, action string, at time.Time) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	previous := fs.data.ModActions
+	for i := len(previous) - 1; i >= 0; i-- {
+		ma := previous[i]
+		if ma.UserID != userID || ma.Action != action || !ma.At.Equal(at) {
+			continue
+		}
+
+		kept := make([]*ModAction, 0, len(previous)-1)
+		kept = append(kept, previous[:i]...)
+		kept = append(kept, previous[i+1:]...)
+		fs.data.ModActions = kept
+		if err := fs.save(); err != nil {
+			fs.data.ModActions = previous
+			return err
+		}
+		return nil
+	}
+	return nil
+}
+
+func (fs *FileStore) PruneModActions(before time.Time) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	previous := fs.data.ModActions
+	kept := make([]*ModAction, 0, len(previous))
+	for _, ma := range previous {
+		if !ma.At.Before(before) {
+			kept = append(kept, ma)
+		}
+	}
+	if len(kept) == len(previous) {
+		return nil
+	}
+
+	fs.data.ModActions = kept
+	if err := fs.save(); err != nil {
+		fs.data.ModActions = previous
+		return err
+	}
+	return nil
+}
+
+func (fs *FileStore) RecordDeparture(d *Departure) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	stored := *d
+	stored.Count = 1
+	previous := fs.data.Departures
+	kept := make([]*Departure, 0, len(previous)+1)
+	for _, old := range previous {
+		if old.UserID == d.UserID {
+			stored.Count += departureCount(old)
+			continue
+		}
+		kept = append(kept, old)
+	}
+
+	fs.data.Departures = append(kept, &stored)
+	if err := fs.save(); err != nil {
+		fs.data.Departures = previous
+		return err
+	}
+	return nil
+}
+
+func (fs *FileStore) LastDeparture(userID string) (*Departure, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	for i := len(fs.data.Departures) - 1; i >= 0; i-- {
+		if d := fs.data.Departures[i]; d.UserID == userID {
+			found := *d
+			return &found, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// departureCount is how many times d stands for; files written before
+// departures were merged have no count
+func departureCount(d *Departure) int {
+	if d.Count < 1 {
+		return 1
+	}
+	return d.Count
+}
+
+// mergeDepartures folds the one-entry-per-leave history of older files
+// into the latest departure of each user
+func mergeDepartures(departures []*Departure) []*Departure {
+	counts := make(map[string]int)
+	latest := make(map[string]*Departure)
+	for _, d := range departures {
+		counts[d.UserID] += departureCount(d)
+		latest[d.UserID] = d
+	}
+
+	merged := make([]*Departure, 0, len(latest))
+	for _, d := range departures {
+		if latest[d.UserID] == d {
+			d.Count = counts[d.UserID]
+			merged = append(merged, d)
+		}
+	}
+	return merged
+}
+
+func (fs *FileStore) CreateFilterRule(r *FilterRule) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	r.ID = fs.data.NextFilterRuleID
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+
+	stored := *r
+	fs.data.FilterRules = append(fs.data.FilterRules, &stored)
+	fs.data.NextFilterRuleID++
+
+	if err := fs.save(); err != nil {
+		fs.data.FilterRules = fs.data.FilterRules[:len(fs.data.FilterRules)-1]
+		fs.data.NextFilterRuleID--
+		return err
+	}
+	return nil
+}
+
+func (fs *FileStore) FilterRules() ([]*FilterRule, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	rules := make([]*FilterRule, 0, len(fs.data.FilterRules))
+	for _, r := range fs.data.FilterRules {
+		found := *r
+		rules = append(rules, &found)
+	}
+	return rules, nil
+}
+
+func (fs *FileStore) DeleteFilterRule(id int) (*FilterRule, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for i, r := range fs.data.FilterRules {
+		if r.ID != id {
+			continue
+		}
+
+		previous := fs.data.FilterRules
+		remaining := make([]*FilterRule, 0, len(previous)-1)
+		remaining = append(remaining, previous[:i]...)
+		remaining = append(remaining, previous[i+1:]...)
+		fs.data.FilterRules = remaining
+
+		if err := fs.save(); err != nil {
+			fs.data.FilterRules = previous
+			return nil, err
+		}
+
+		deleted := *r
+		return &deleted, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (fs *FileStore) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.save()
+}
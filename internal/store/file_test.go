@@ -0,0 +1,89 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordDepartureKeepsLatest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	fs, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	for i, roles := range [][]string{{"a"}, {"b"}, {"c"}} {
+		d := &Departure{UserID: "1", LeftAt: start.Add(time.Duration(i) * time.Hour), Roles: roles}
+		if err := fs.RecordDeparture(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d := &Departure{UserID: "2", LeftAt: start, Roles: []string{"x"}}
+	if err := fs.RecordDeparture(d); err != nil {
+		t.Fatal(err)
+	}
+	// The store keeps its own copy
+	d.Roles = nil
+	if got, _ := fs.LastDeparture("2"); got == nil || len(got.Roles) != 1 {
+		t.Errorf("changing the recorded departure changed the stored one: %+v", got)
+	}
+
+	if len(fs.data.Departures) != 2 {
+		t.Errorf("%d departures stored, want one per user", len(fs.data.Departures))
+	}
+
+	last, err := fs.LastDeparture("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Count != 3 || len(last.Roles) != 1 || last.Roles[0] != "c" {
+		t.Errorf("LastDeparture = %+v, want the third departure with roles [c]", last)
+	}
+
+	if _, err := fs.LastDeparture("3"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("LastDeparture of a user who never left = %v, want ErrNotFound", err)
+	}
+}
+
+func TestOpenMergesDepartures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	raw := `{"departures": [
+		{"user_id": "1", "left_at": "2024-01-01T00:00:00Z", "roles": ["a"]},
+		{"user_id": "2", "left_at": "2024-01-02T00:00:00Z"},
+		{"user_id": "1", "left_at": "2024-01-03T00:00:00Z", "roles": ["b"]},
+		{"user_id": "1", "left_at": "2024-01-04T00:00:00Z", "roles": ["c"], "count": 2}
+	]}`
+	if err := os.WriteFile(path, []byte(raw), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		userID string
+		count  int
+		day    int
+	}{
+		{"1", 4, 4},
+		{"2", 1, 2},
+	}
+	for _, tt := range tests {
+		d, err := fs.LastDeparture(tt.userID)
+		if err != nil {
+			t.Fatalf("LastDeparture(%s): %v", tt.userID, err)
+		}
+		if d.Count != tt.count || d.LeftAt.Day() != tt.day {
+			t.Errorf("LastDeparture(%s) = %d time(s), left on day %d; want %d, day %d", tt.userID, d.Count, d.LeftAt.Day(), tt.count, tt.day)
+		}
+	}
+	if len(fs.data.Departures) != 2 {
+		t.Errorf("%d departures after loading, want one per user", len(fs.data.Departures))
+	}
+}
@@ -0,0 +1,130 @@
+package store
+
+import (
+	"errors"
+	"time"
+)
+
+// Case action types
+const (
+	ActionBan     = "ban"
+	ActionTempban = "tempban"
+	ActionKick    = "kick"
+	ActionMute    = "mute"
+	ActionUnban   = "unban"
+	ActionUnmute  = "unmute"
+	ActionWarn    = "warn"
+
+	ActionQuarantine = "quarantine"
+)
+
+// ErrNotFound is returned when a record doesn't exist
+var ErrNotFound = errors.New("not found")
+
+// Case is a single recorded moderation action
+type Case struct {
+	ID          int       `json:"id"`
+	GuildID     string    `json:"guild_id"`
+	Action      string    `json:"action"`
+	ModeratorID string    `json:"moderator_id"`
+	TargetID    string    `json:"target_id"`
+	Reason      string    `json:"reason"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at,omitempty"` // zero for permanent actions
+
+	// Log channel message describing this case, so it can be edited later
+	LogChannelID string `json:"log_channel_id,omitempty"`
+	LogMessageID string `json:"log_message_id,omitempty"`
+}
+
+// Warning is an active warning against a user. Removing a warning deletes
+// it here; the case recorded when it was issued stays for the audit trail.
+type Warning struct {
+	ID          int       `json:"id"`
+	GuildID     string    `json:"guild_id"`
+	UserID      string    `json:"user_id"`
+	ModeratorID string    `json:"moderator_id"`
+	Reason      string    `json:"reason"`
+	CaseID      int       `json:"case_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ModAction is one quota-limited action performed by a moderator
+type ModAction struct {
+	UserID string    `json:"user_id"`
+	Action string    `json:"action"`
+	At     time.Time `json:"at"`
+}
+
+// Departure is a member leaving the guild, kept to spot returning members
+// and restore their sticky roles
+type Departure struct {
+	UserID   string    `json:"user_id"`
+	JoinedAt time.Time `json:"joined_at,omitempty"` // zero if unknown
+	LeftAt   time.Time `json:"left_at"`
+	Roles    []string  `json:"roles,omitempty"` // sticky roles to give back on rejoin
+	Count    int       `json:"count,omitempty"` // times the member has left, this one included
+}
+
+// Filter rule kinds
+const (
+	FilterWord     = "word"     // whole word or phrase
+	FilterWildcard = "wildcard" // word with * and ? wildcards
+	FilterRegex    = "regex"    // regular expression
+)
+
+// FilterRule is one entry of the automod blocklist
+type FilterRule struct {
+	ID        int       `json:"id"`
+	Kind      string    `json:"kind"`
+	Pattern   string    `json:"pattern"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store persists moderation data across restarts
+type Store interface {
+	// CreateCase assigns the next case number and CreatedAt to c and saves it
+	CreateCase(c *Case) error
+	// Case returns the case with the given number or ErrNotFound
+	Case(id int) (*Case, error)
+	// CasesForUser returns all cases against targetID, oldest first
+	CasesForUser(targetID string) ([]*Case, error)
+	// UpdateCase replaces the stored case with the same ID
+	UpdateCase(c *Case) error
+	// DeleteCase removes a case that should never have been recorded, e.g.
+	// because the rest of its action failed, or returns ErrNotFound
+	DeleteCase(id int) error
+
+	// CreateWarning assigns the next warning number and CreatedAt to w and saves it
+	CreateWarning(w *Warning) error
+	// WarningsForUser returns all active warnings for userID, oldest first
+	WarningsForUser(userID string) ([]*Warning, error)
+	// DeleteWarning removes a warning and returns it, or ErrNotFound
+	DeleteWarning(id int) (*Warning, error)
+
+	// ModActionsSince returns when userID performed action at or after
+	// since, oldest first
+	ModActionsSince(userID, action string, since time.Time) ([]time.Time, error)
+	// RecordModAction stores that userID performed action at the given time
+	RecordModAction(userID, action string, at time.Time) error
+	// RemoveModAction takes back a recorded action, e.g. one that failed
+	RemoveModAction(userID, action string, at time.Time) error
+	// PruneModActions drops every recorded action older than before
+	PruneModActions(before time.Time) error
+
+	// RecordDeparture stores that a member left the guild, replacing their
+	// previous departure and counting it
+	RecordDeparture(d *Departure) error
+	// LastDeparture returns the latest departure of userID or ErrNotFound
+	LastDeparture(userID string) (*Departure, error)
+
+	// CreateFilterRule assigns the next rule number and CreatedAt to r and saves it
+	CreateFilterRule(r *FilterRule) error
+	// FilterRules returns every blocklist rule, oldest first
+	FilterRules() ([]*FilterRule, error)
+	// DeleteFilterRule removes a rule and returns it, or ErrNotFound
+	DeleteFilterRule(id int) (*FilterRule, error)
+
+	Close() error
+}
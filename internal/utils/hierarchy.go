@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// HierarchyError explains why a moderation action was refused by
+// CheckHierarchy. Its message is meant to be shown to the moderator.
+type HierarchyError struct {
+	Reason string
+}
+
+func (e *HierarchyError) Error() string {
+	return e.Reason
+}
+
+// CheckHierarchy verifies that moderatorID may act on targetID: nobody can
+// target themselves, the bot or the guild owner, and the target's highest
+// role must be below both the moderator's (unless the moderator owns the
+// guild) and the bot's. Targets that aren't members (e.g. banning by ID)
+// have no roles and always pass the role comparison.
+// Returns a *HierarchyError on refusal.
+func CheckHierarchy(s *discordgo.Session, guildID, moderatorID, targetID string) error {
+	guild, err := s.State.Guild(guildID)
+	if err != nil {
+		guild, err = s.Guild(guildID)
+		if err != nil {
+			return err
+		}
+	}
+
+	botID := ""
+	if s.State != nil && s.State.User != nil {
+		botID = s.State.User.ID
+	}
+
+	if targetID == moderatorID {
+		return &HierarchyError{Reason: "You can't moderate yourself."}
+	}
+	if targetID == botID {
+		return &HierarchyError{Reason: "I can't moderate myself."}
+	}
+	if targetID == guild.OwnerID {
+		return &HierarchyError{Reason: "The server owner can't be moderated."}
+	}
+
+	target, err := GetMember(s, guildID, targetID)
+	if err != nil {
+		var restErr *discordgo.RESTError
+		if errors.As(err, &restErr) && restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound {
+			return nil // Not in the guild, nothing to compare
+		}
+		return err
+	}
+
+	positions := make(map[string]int, len(guild.Roles))
+	for _, role := range guild.Roles {
+		positions[role.ID] = role.Position
+	}
+
+	targetPos := highestRolePosition(positions, target.Roles)
+
+	// The guild owner outranks every role
+	if moderatorID != guild.OwnerID && moderatorID != botID {
+		moderator, err := GetMember(s, guildID, moderatorID)
+		if err != nil {
+			return err
+		}
+		if targetPos >= highestRolePosition(positions, moderator.Roles) {
+			return &HierarchyError{Reason: "The target has an equal or higher role than you."}
+		}
+	}
+
+	if botID != "" {
+		bot, err := GetMember(s, guildID, botID)
+		if err != nil {
+			return err
+		}
+		if targetPos >= highestRolePosition(positions, bot.Roles) {
+			return &HierarchyError{Reason: "The target has an equal or higher role than the bot. Move the bot's role above theirs."}
+		}
+	}
+
+	return nil
+}
+
+// highestRolePosition returns the position of the highest role in roleIDs,
+// or 0 (@everyone) for members without roles
+func highestRolePosition(positions map[string]int, roleIDs []string) int {
+	highest := 0
+	for _, roleID := range roleIDs {
+		if pos, ok := positions[roleID]; ok && pos > highest {
+			highest = pos
+		}
+	}
+	return highest
+}
@@ -0,0 +1,483 @@
+package utils
+
+import (
+	"discord-mod-bot/internal/config"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Quota-limited action types
+const (
+	ActionBan   = "ban"
+	ActionKick  = "kick"
+	ActionMute  = "mute"
+	ActionWarn  = "warn"
+	ActionPurge = "purge"
+)
+
+// QuotaActions lists every action a quota can be configured for
+var QuotaActions = []string{ActionBan, ActionKick, ActionMute, ActionWarn, ActionPurge}
+
+// DefaultQuotaPolicy keeps the original limit of 10 bans and 10 kicks per
+// 24 hours for mods, with staff unlimited
+const DefaultQuotaPolicy = "mod:ban=10/24h,kick=10/24h"
+
+var (
+	modActionCounter ModActionCounter = &memoryCounter{}
+	quotaPolicy                       = mustParseQuotaPolicy(DefaultQuotaPolicy)
+	rateLimitMux     sync.Mutex       // Serializes check-and-record of mod actions
+)
+
+// ModActionCounter stores when moderators performed quota-limited actions
+type ModActionCounter interface {
+	ModActionsSince(userID, action string, since time.Time) ([]time.Time, error)
+	RecordModAction(userID, action string, at time.Time) error
+	RemoveModAction(userID, action string, at time.Time) error
+	PruneModActions(before time.Time) error
+}
+
+// QuotaLimit allows Max actions in any rolling Window
+type QuotaLimit struct {
+	Max    int
+	Window time.Duration
+}
+
+func (l QuotaLimit) String() string {
+	return fmt.Sprintf("%d per %s", l.Max, shortDuration(l.Window))
+}
+
+// QuotaTier holds the limits of one tier: RoleMod, RoleStaff or a custom role ID
+type QuotaTier struct {
+	Name   string
+	Limits map[string][]QuotaLimit // action -> limits, all of which must hold
+}
+
+// QuotaPolicy is the full set of configured tiers
+type QuotaPolicy struct {
+	Tiers map[string]*QuotaTier
+	// CustomOrder is the config order of custom role tiers, which take
+	// precedence over staff and mod
+	CustomOrder []string
+}
+
+// QuotaExceededError is returned by CheckAndRecordModAction when a limit is hit
+type QuotaExceededError struct {
+	Action  string
+	Limit   QuotaLimit
+	RetryAt time.Time
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s limit reached (%s)", e.Action, e.Limit)
+}
+
+// QuotaUsage is the state of one limit for !quota
+type QuotaUsage struct {
+	Action    string
+	Limit     QuotaLimit
+	Used      int
+	Remaining int
+	ResetAt   time.Time // when the oldest counted action leaves the window
+}
+
+// SetModActionCounter replaces the in-memory counter with durable storage
+// so limits survive restarts
+func SetModActionCounter(c ModActionCounter) {
+	rateLimitMux.Lock()
+	defer rateLimitMux.Unlock()
+	modActionCounter = c
+}
+
+// SetQuotaPolicy replaces the active quota policy
+func SetQuotaPolicy(p *QuotaPolicy) {
+	rateLimitMux.Lock()
+	defer rateLimitMux.Unlock()
+	quotaPolicy = p
+}
+
+// ParseQuotaPolicy parses MOD_QUOTAS, e.g.
+// "mod:ban=3/1h,ban=10/24h,kick=10/24h;staff:ban=50/24h;123456789012345678:mute=20/1h".
+// Tiers are separated by ";", limits by ",". An action without limits in a
+// tier is unlimited for that tier; a maximum of 0 forbids the action.
+func ParseQuotaPolicy(raw string) (*QuotaPolicy, error) {
+	policy := &QuotaPolicy{Tiers: make(map[string]*QuotaTier)}
+
+	for _, tierPart := range strings.Split(raw, ";") {
+		tierPart = strings.TrimSpace(tierPart)
+		if tierPart == "" {
+			continue
+		}
+
+		name, limitsStr, ok := strings.Cut(tierPart, ":")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid quota tier %q", tierPart)
+		}
+		if name != RoleMod && name != RoleStaff {
+			if _, err := strconv.ParseUint(name, 10, 64); err != nil {
+				return nil, fmt.Errorf("quota tier %q must be mod, staff or a role ID", name)
+			}
+		}
+		if _, exists := policy.Tiers[name]; exists {
+			return nil, fmt.Errorf("quota tier %q configured twice", name)
+		}
+
+		tier := &QuotaTier{Name: name, Limits: make(map[string][]QuotaLimit)}
+		for _, limitPart := range strings.Split(limitsStr, ",") {
+			limitPart = strings.TrimSpace(limitPart)
+			if limitPart == "" {
+				continue
+			}
+
+			action, limit, err := parseQuotaLimit(limitPart)
+			if err != nil {
+				return nil, fmt.Errorf("tier %q: %w", name, err)
+			}
+			tier.Limits[action] = append(tier.Limits[action], limit)
+		}
+
+		policy.Tiers[name] = tier
+		if name != RoleMod && name != RoleStaff {
+			policy.CustomOrder = append(policy.CustomOrder, name)
+		}
+	}
+
+	return policy, nil
+}
+
+// parseQuotaLimit parses "ban=10/24h"
+func parseQuotaLimit(raw string) (string, QuotaLimit, error) {
+	action, rest, ok := strings.Cut(raw, "=")
+	action = strings.ToLower(strings.TrimSpace(action))
+	if !ok {
+		return "", QuotaLimit{}, fmt.Errorf("invalid limit %q (expected action=max/window)", raw)
+	}
+
+	known := false
+	for _, a := range QuotaActions {
+		if a == action {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return "", QuotaLimit{}, fmt.Errorf("unknown action %q in limit %q", action, raw)
+	}
+
+	maxStr, windowStr, ok := strings.Cut(rest, "/")
+	if !ok {
+		return "", QuotaLimit{}, fmt.Errorf("invalid limit %q (expected action=max/window)", raw)
+	}
+
+	maxActions, err := strconv.Atoi(strings.TrimSpace(maxStr))
+	if err != nil || maxActions < 0 {
+		return "", QuotaLimit{}, fmt.Errorf("invalid maximum in limit %q", raw)
+	}
+
+	window, err := time.ParseDuration(strings.TrimSpace(windowStr))
+	if err != nil || window <= 0 {
+		return "", QuotaLimit{}, fmt.Errorf("invalid window in limit %q (use e.g. 1h or 24h)", raw)
+	}
+
+	return action, QuotaLimit{Max: maxActions, Window: window}, nil
+}
+
+func mustParseQuotaPolicy(raw string) *QuotaPolicy {
+	p, err := ParseQuotaPolicy(raw)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// longestWindow is how far back actions have to be kept
+func (p *QuotaPolicy) longestWindow() time.Duration {
+	var longest time.Duration
+	for _, tier := range p.Tiers {
+		for _, limits := range tier.Limits {
+			for _, l := range limits {
+				if l.Window > longest {
+					longest = l.Window
+				}
+			}
+		}
+	}
+	return longest
+}
+
+// tierFor picks the tier that applies to a member: admins are never limited,
+// then custom role tiers in config order, then staff, then mod. Returns nil
+// if no limits apply.
+func (p *QuotaPolicy) tierFor(member *discordgo.Member) *QuotaTier {
+	roleMap := make(map[string]bool, len(member.Roles))
+	for _, roleID := range member.Roles {
+		roleMap[roleID] = true
+	}
+
+	if config.Cfg.AdminRoleID != "" && roleMap[config.Cfg.AdminRoleID] {
+		return nil
+	}
+
+	for _, roleID := range p.CustomOrder {
+		if roleMap[roleID] {
+			return p.Tiers[roleID]
+		}
+	}
+
+	if config.Cfg.StaffRoleID != "" && roleMap[config.Cfg.StaffRoleID] {
+		return p.Tiers[RoleStaff]
+	}
+
+	if config.Cfg.ModRoleID != "" && roleMap[config.Cfg.ModRoleID] {
+		return p.Tiers[RoleMod]
+	}
+
+	return nil
+}
+
+// quotaUsage evaluates every limit of tier for action. Caller must hold rateLimitMux.
+func quotaUsage(tier *QuotaTier, userID, action string, now time.Time) ([]QuotaUsage, error) {
+	var usage []QuotaUsage
+	for _, limit := range tier.Limits[action] {
+		times, err := modActionCounter.ModActionsSince(userID, action, now.Add(-limit.Window))
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s history: %w", action, err)
+		}
+
+		u := QuotaUsage{
+			Action:    action,
+			Limit:     limit,
+			Used:      len(times),
+			Remaining: limit.Max - len(times),
+		}
+		if u.Remaining < 0 {
+			u.Remaining = 0
+		}
+
+		// The slot that frees up next belongs to the oldest action still
+		// counting against the limit. A limit of 0 never frees up.
+		switch {
+		case limit.Max == 0 || len(times) == 0:
+		case len(times) >= limit.Max:
+			u.ResetAt = times[len(times)-limit.Max].Add(limit.Window)
+		default:
+			u.ResetAt = times[0].Add(limit.Window)
+		}
+
+		usage = append(usage, u)
+	}
+	return usage, nil
+}
+
+// ModActionRecord is an action counted against a quota before it is
+// carried out. Cancel gives the slot back if the action then fails.
+type ModActionRecord struct {
+	userID string
+	action string
+	at     time.Time
+}
+
+// CheckAndRecordModAction checks the user's quota for an action and, if it
+// is allowed, counts it under the same lock so two concurrent commands can't
+// both take the last slot. Returns a *QuotaExceededError when a limit is reached.
+func CheckAndRecordModAction(s *discordgo.Session, guildID, userID, actionType string) (*ModActionRecord, error) {
+	member, err := GetMember(s, guildID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimitMux.Lock()
+	defer rateLimitMux.Unlock()
+
+	now := time.Now()
+	if err := quotaExceeded(member, userID, actionType, now); err != nil {
+		return nil, err
+	}
+
+	recordModAction(userID, actionType, now)
+	return &ModActionRecord{userID: userID, action: actionType, at: now}, nil
+}
+
+// CountModAction counts an action that already happened, e.g. one taken in
+// the Discord client. Returns a *QuotaExceededError if it went over a limit.
+func CountModAction(s *discordgo.Session, guildID, userID, actionType string) error {
+	member, memberErr := GetMember(s, guildID, userID)
+
+	rateLimitMux.Lock()
+	defer rateLimitMux.Unlock()
+
+	now := time.Now()
+	var err error
+	if memberErr != nil {
+		err = memberErr
+	} else {
+		err = quotaExceeded(member, userID, actionType, now)
+	}
+
+	recordModAction(userID, actionType, now)
+	return err
+}
+
+// Cancel removes the recorded action again. It is safe to call on nil.
+// Thread-safe with mutex
+func (r *ModActionRecord) Cancel() {
+	if r == nil {
+		return
+	}
+
+	rateLimitMux.Lock()
+	defer rateLimitMux.Unlock()
+
+	if err := modActionCounter.RemoveModAction(r.userID, r.action, r.at); err != nil {
+		log.Printf("Rate limit: Error removing %s for user %s: %v", r.action, r.userID, err)
+	}
+}
+
+// quotaExceeded returns a *QuotaExceededError if the member's tier doesn't
+// allow another action. Caller must hold rateLimitMux.
+func quotaExceeded(member *discordgo.Member, userID, actionType string, now time.Time) error {
+	tier := quotaPolicy.tierFor(member)
+	if tier == nil {
+		return nil
+	}
+
+	usage, err := quotaUsage(tier, userID, actionType, now)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range usage {
+		if u.Remaining <= 0 {
+			return &QuotaExceededError{Action: actionType, Limit: u.Limit, RetryAt: u.ResetAt}
+		}
+	}
+	return nil
+}
+
+// recordModAction records a mod action for rate limiting and drops history
+// that no limit looks at anymore. Caller must hold rateLimitMux.
+func recordModAction(userID, actionType string, now time.Time) {
+	if err := modActionCounter.RecordModAction(userID, actionType, now); err != nil {
+		log.Printf("Rate limit: Error recording %s for user %s: %v", actionType, userID, err)
+	}
+
+	if err := modActionCounter.PruneModActions(now.Add(-quotaPolicy.longestWindow())); err != nil {
+		log.Printf("Rate limit: Error removing old actions: %v", err)
+	}
+}
+
+// CleanupOldCounts removes actions older than the longest configured window
+// Thread-safe with mutex
+func CleanupOldCounts() {
+	rateLimitMux.Lock()
+	defer rateLimitMux.Unlock()
+
+	if err := modActionCounter.PruneModActions(time.Now().Add(-quotaPolicy.longestWindow())); err != nil {
+		log.Printf("Rate limit: Error removing old actions: %v", err)
+	}
+}
+
+// GetQuotaUsage returns the tier name and the state of every limit that
+// applies to the user. An empty tier name means the user is unlimited.
+func GetQuotaUsage(s *discordgo.Session, guildID, userID string) (string, []QuotaUsage, error) {
+	member, err := GetMember(s, guildID, userID)
+	if err != nil {
+		return "", nil, err
+	}
+	if member == nil {
+		return "", nil, nil
+	}
+
+	rateLimitMux.Lock()
+	defer rateLimitMux.Unlock()
+
+	tier := quotaPolicy.tierFor(member)
+	if tier == nil {
+		return "", nil, nil
+	}
+
+	actions := make([]string, 0, len(tier.Limits))
+	for action := range tier.Limits {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+
+	now := time.Now()
+	var all []QuotaUsage
+	for _, action := range actions {
+		usage, err := quotaUsage(tier, userID, action, now)
+		if err != nil {
+			return "", nil, err
+		}
+		all = append(all, usage...)
+	}
+
+	return tier.Name, all, nil
+}
+
+// shortDuration renders whole hours/minutes compactly, e.g. 24h or 30m
+func shortDuration(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	if d%time.Minute == 0 {
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return d.String()
+}
+
+// memoryCounter is the process-local fallback used until durable storage
+// is configured with SetModActionCounter
+type memoryCounter struct {
+	actions []memoryAction
+}
+
+type memoryAction struct {
+	userID string
+	action string
+	at     time.Time
+}
+
+func (mc *memoryCounter) ModActionsSince(userID, action string, since time.Time) ([]time.Time, error) {
+	var times []time.Time
+	for _, a := range mc.actions {
+		if a.userID == userID && a.action == action && !a.at.Before(since) {
+			times = append(times, a.at)
+		}
+	}
+	return times, nil
+}
+
+func (mc *memoryCounter) RecordModAction(userID, action string, at time.Time) error {
+	mc.actions = append(mc.actions, memoryAction{userID: userID, action: action, at: at})
+	return nil
+}
+
+func (mc *memoryCounter) RemoveModAction(userID, action string, at time.Time) error {
+	for i := len(mc.actions) - 1; i >= 0; i-- {
+		a := mc.actions[i]
+		if a.userID == userID && a.action == action && a.at.Equal(at) {
+			mc.actions = append(mc.actions[:i], mc.actions[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (mc *memoryCounter) PruneModActions(before time.Time) error {
+	kept := mc.actions[:0]
+	for _, a := range mc.actions {
+		if !a.at.Before(before) {
+			kept = append(kept, a)
+		}
+	}
+	mc.actions = kept
+	return nil
+}
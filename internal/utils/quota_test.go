@@ -0,0 +1,272 @@
+package utils
+
+import (
+	"discord-mod-bot/internal/config"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestParseQuotaPolicy(t *testing.T) {
+	tests := []struct {
+		raw     string
+		wantErr bool
+		tiers   map[string]map[string][]QuotaLimit
+		custom  []string
+	}{
+		{
+			raw: "mod:ban=3/1h,ban=10/24h,kick=10/24h",
+			tiers: map[string]map[string][]QuotaLimit{
+				RoleMod: {
+					ActionBan:  {{Max: 3, Window: time.Hour}, {Max: 10, Window: 24 * time.Hour}},
+					ActionKick: {{Max: 10, Window: 24 * time.Hour}},
+				},
+			},
+		},
+		{
+			raw: " MOD : ban=0/24h ; staff:purge=5/30m;123456789012345678:mute=20/1h ",
+			tiers: map[string]map[string][]QuotaLimit{
+				RoleMod:              {ActionBan: {{Max: 0, Window: 24 * time.Hour}}},
+				RoleStaff:            {ActionPurge: {{Max: 5, Window: 30 * time.Minute}}},
+				"123456789012345678": {ActionMute: {{Max: 20, Window: time.Hour}}},
+			},
+			custom: []string{"123456789012345678"},
+		},
+		{raw: "", tiers: map[string]map[string][]QuotaLimit{}},
+		{raw: "mod:", tiers: map[string]map[string][]QuotaLimit{RoleMod: {}}},
+		{raw: "admin:ban=1/1h", wantErr: true},
+		{raw: "mod:ban=1/1h;mod:kick=1/1h", wantErr: true},
+		{raw: "mod:nuke=1/1h", wantErr: true},
+		{raw: "mod:ban=-1/1h", wantErr: true},
+		{raw: "mod:ban=x/1h", wantErr: true},
+		{raw: "mod:ban=1", wantErr: true},
+		{raw: "mod:ban=1/0h", wantErr: true},
+		{raw: "mod:ban=1/soon", wantErr: true},
+		{raw: "mod:ban", wantErr: true},
+		{raw: "ban=1/1h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		policy, err := ParseQuotaPolicy(tt.raw)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseQuotaPolicy(%q) succeeded, want error", tt.raw)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseQuotaPolicy(%q) error: %v", tt.raw, err)
+			continue
+		}
+
+		if len(policy.Tiers) != len(tt.tiers) {
+			t.Errorf("ParseQuotaPolicy(%q) has %d tiers, want %d", tt.raw, len(policy.Tiers), len(tt.tiers))
+		}
+		for name, limits := range tt.tiers {
+			tier := policy.Tiers[name]
+			if tier == nil {
+				t.Errorf("ParseQuotaPolicy(%q) is missing tier %q", tt.raw, name)
+				continue
+			}
+			if len(tier.Limits) != len(limits) {
+				t.Errorf("ParseQuotaPolicy(%q) tier %q has %d actions, want %d", tt.raw, name, len(tier.Limits), len(limits))
+			}
+			for action, want := range limits {
+				got := tier.Limits[action]
+				if len(got) != len(want) {
+					t.Errorf("ParseQuotaPolicy(%q) %s/%s = %v, want %v", tt.raw, name, action, got, want)
+					continue
+				}
+				for i := range want {
+					if got[i] != want[i] {
+						t.Errorf("ParseQuotaPolicy(%q) %s/%s = %v, want %v", tt.raw, name, action, got, want)
+					}
+				}
+			}
+		}
+
+		if len(policy.CustomOrder) != len(tt.custom) {
+			t.Errorf("ParseQuotaPolicy(%q) custom order = %v, want %v", tt.raw, policy.CustomOrder, tt.custom)
+		}
+	}
+}
+
+func TestQuotaUsage(t *testing.T) {
+	now := time.Now()
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+
+	tests := []struct {
+		name          string
+		limit         QuotaLimit
+		actions       []time.Time
+		wantUsed      int
+		wantRemaining int
+		wantResetAt   time.Time
+	}{
+		{
+			name:          "unused",
+			limit:         QuotaLimit{Max: 3, Window: time.Hour},
+			wantRemaining: 3,
+		},
+		{
+			name:          "partly used",
+			limit:         QuotaLimit{Max: 3, Window: time.Hour},
+			actions:       []time.Time{ago(50 * time.Minute), ago(10 * time.Minute)},
+			wantUsed:      2,
+			wantRemaining: 1,
+			wantResetAt:   ago(50 * time.Minute).Add(time.Hour),
+		},
+		{
+			name:          "used up",
+			limit:         QuotaLimit{Max: 2, Window: time.Hour},
+			actions:       []time.Time{ago(50 * time.Minute), ago(30 * time.Minute), ago(10 * time.Minute)},
+			wantUsed:      3,
+			wantRemaining: 0,
+			wantResetAt:   ago(30 * time.Minute).Add(time.Hour),
+		},
+		{
+			name:          "outside the window",
+			limit:         QuotaLimit{Max: 2, Window: time.Hour},
+			actions:       []time.Time{ago(3 * time.Hour), ago(2 * time.Hour)},
+			wantRemaining: 2,
+		},
+		{
+			name:          "forbidden",
+			limit:         QuotaLimit{Max: 0, Window: 24 * time.Hour},
+			actions:       []time.Time{ago(time.Hour)},
+			wantUsed:      1,
+			wantRemaining: 0,
+		},
+		{
+			name:          "forbidden and unused",
+			limit:         QuotaLimit{Max: 0, Window: 24 * time.Hour},
+			wantRemaining: 0,
+		},
+	}
+
+	previous := modActionCounter
+	defer func() { modActionCounter = previous }()
+
+	for _, tt := range tests {
+		counter := &memoryCounter{}
+		for _, at := range tt.actions {
+			counter.RecordModAction("mod", ActionBan, at)
+		}
+		// Other users and actions never count
+		counter.RecordModAction("other", ActionBan, now)
+		counter.RecordModAction("mod", ActionKick, now)
+		modActionCounter = counter
+
+		tier := &QuotaTier{Name: RoleMod, Limits: map[string][]QuotaLimit{ActionBan: {tt.limit}}}
+		usage, err := quotaUsage(tier, "mod", ActionBan, now)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(usage) != 1 {
+			t.Fatalf("%s: got %d usages, want 1", tt.name, len(usage))
+		}
+
+		u := usage[0]
+		if u.Used != tt.wantUsed || u.Remaining != tt.wantRemaining || !u.ResetAt.Equal(tt.wantResetAt) {
+			t.Errorf("%s: used %d, remaining %d, reset %v; want %d, %d, %v", tt.name,
+				u.Used, u.Remaining, u.ResetAt, tt.wantUsed, tt.wantRemaining, tt.wantResetAt)
+		}
+	}
+}
+
+// quotaTestSession returns a session whose state holds one moderator
+func quotaTestSession(t *testing.T) *discordgo.Session {
+	t.Helper()
+
+	previousCfg, previousPolicy, previousCounter := config.Cfg, quotaPolicy, modActionCounter
+	t.Cleanup(func() {
+		config.Cfg, quotaPolicy, modActionCounter = previousCfg, previousPolicy, previousCounter
+	})
+
+	config.Cfg = &config.Config{ModRoleID: "200000000000000000"}
+	quotaPolicy = mustParseQuotaPolicy("mod:ban=1/1h")
+	modActionCounter = &memoryCounter{}
+
+	s := &discordgo.Session{State: discordgo.NewState()}
+	if err := s.State.GuildAdd(&discordgo.Guild{ID: "100000000000000000"}); err != nil {
+		t.Fatal(err)
+	}
+	member := &discordgo.Member{
+		GuildID: "100000000000000000",
+		User:    &discordgo.User{ID: "300000000000000000"},
+		Roles:   []string{"200000000000000000"},
+	}
+	if err := s.State.MemberAdd(member); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestCheckAndRecordModActionConcurrent(t *testing.T) {
+	s := quotaTestSession(t)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := CheckAndRecordModAction(s, "100000000000000000", "300000000000000000", ActionBan); err == nil {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 1 {
+		t.Errorf("%d concurrent bans allowed, want 1", allowed)
+	}
+}
+
+func TestModActionRecordCancel(t *testing.T) {
+	s := quotaTestSession(t)
+
+	record, err := CheckAndRecordModAction(s, "100000000000000000", "300000000000000000", ActionBan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var quotaErr *QuotaExceededError
+	if _, err := CheckAndRecordModAction(s, "100000000000000000", "300000000000000000", ActionBan); !errors.As(err, &quotaErr) {
+		t.Fatalf("second ban: got %v, want a quota error", err)
+	}
+
+	// A failed ban gives its slot back
+	record.Cancel()
+	if _, err := CheckAndRecordModAction(s, "100000000000000000", "300000000000000000", ActionBan); err != nil {
+		t.Errorf("ban after cancel: %v", err)
+	}
+
+	var nilRecord *ModActionRecord
+	nilRecord.Cancel()
+}
+
+func TestCountModAction(t *testing.T) {
+	s := quotaTestSession(t)
+
+	if err := CountModAction(s, "100000000000000000", "300000000000000000", ActionBan); err != nil {
+		t.Fatalf("first ban: %v", err)
+	}
+
+	// Actions outside the bot already happened, so they are counted anyway
+	var quotaErr *QuotaExceededError
+	if err := CountModAction(s, "100000000000000000", "300000000000000000", ActionBan); !errors.As(err, &quotaErr) {
+		t.Errorf("second ban: got %v, want a quota error", err)
+	}
+
+	times, _ := modActionCounter.ModActionsSince("300000000000000000", ActionBan, time.Now().Add(-time.Hour))
+	if len(times) != 2 {
+		t.Errorf("%d bans recorded, want 2", len(times))
+	}
+}